@@ -3,6 +3,7 @@ package main
 import (
 	"VitaTaskGo/internal/api"
 	"VitaTaskGo/internal/api/middleware"
+	"VitaTaskGo/internal/api/service"
 	"VitaTaskGo/internal/pkg/workflow"
 	"VitaTaskGo/pkg/config"
 	"VitaTaskGo/pkg/db"
@@ -10,6 +11,7 @@ import (
 	"flag"
 	"github.com/gin-gonic/gin"
 	"strconv"
+	"time"
 )
 
 var configFile = flag.String("f", "config/app.yaml", "the config file")
@@ -32,6 +34,10 @@ func main() {
 	initDatabases()
 	// 初始化工作流
 	workflow.Init()
+	// 启动工作流审批超时扫描
+	go workflow.RunTimeoutScheduler(db.Db, time.Duration(config.Get().Workflow.TimeoutScanInterval)*time.Second)
+	// 启动重复任务生成
+	go service.RunTaskRecurrenceScheduler(db.Db, time.Duration(config.Get().Task.RecurrenceScanInterval)*time.Second)
 	// 初始化Gin
 	r := gin.Default()
 	// 注册中间件
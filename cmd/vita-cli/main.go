@@ -2,6 +2,8 @@ package main
 
 import (
 	"VitaTaskGo/internal/cli"
+	// 注册命令
+	_ "VitaTaskGo/internal/cli/command"
 	"VitaTaskGo/pkg/config"
 	"VitaTaskGo/pkg/db"
 	"VitaTaskGo/pkg/log"
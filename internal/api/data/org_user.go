@@ -0,0 +1,34 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrgUserRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewOrgUserRepo(tx *gorm.DB, ctx *gin.Context) repo.OrgUserRepo {
+	return &OrgUserRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *OrgUserRepo) GetUserOrgIds(uid uint64) ([]int, error) {
+	var ids []int
+	err := r.tx.Model(&repo.OrgUser{}).Where("uid = ?", uid).Distinct().Pluck("org_id", &ids).Error
+	return ids, err
+}
+
+func (r *OrgUserRepo) GetOrgUsersByRole(orgIds []int, role int) ([]repo.OrgUser, error) {
+	var list []repo.OrgUser
+	if len(orgIds) <= 0 {
+		return list, nil
+	}
+	err := r.tx.Where("org_id IN ?", orgIds).Where("role = ?", role).Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,25 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewOrganizationRepo(tx *gorm.DB, ctx *gin.Context) repo.OrganizationRepo {
+	return &OrganizationRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *OrganizationRepo) Get(id int) (*repo.Organization, error) {
+	var d *repo.Organization
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
@@ -62,6 +62,13 @@ func (r *TaskRepo) PageListProject(query dto.TaskListQueryBO) ([]repo.Task, int6
 		tx = tx.Where("project_id IN ?", query.ProjectIds)
 	}
 
+	// 只查询该任务的直接子任务，或者只查询顶级任务
+	if query.ParentId > 0 {
+		tx = tx.Where("parent_id = ?", query.ParentId)
+	} else if query.TopLevel {
+		tx = tx.Where("parent_id = ?", 0)
+	}
+
 	// 任务组搜索
 	if query.GroupId > 0 {
 		tx = tx.Where("group_id = ?", query.GroupId)
@@ -75,6 +82,10 @@ func (r *TaskRepo) PageListProject(query dto.TaskListQueryBO) ([]repo.Task, int6
 	if len(query.CollaboratorTaskIds) > 0 {
 		tx = tx.Where("id IN ?", query.CollaboratorTaskIds)
 	}
+	// 自定义字段
+	if len(query.FieldTaskIds) > 0 {
+		tx = tx.Where("id IN ?", query.FieldTaskIds)
+	}
 	// 时间范围
 	if len(query.CreateTime) >= 2 {
 		createTimeRange, err := time_tool.ParseStartEndTimeToUnix(query.CreateTime, time.DateOnly, "milli")
@@ -120,6 +131,7 @@ func (r *TaskRepo) PageListProject(query dto.TaskListQueryBO) ([]repo.Task, int6
 		Scopes(db.Paginate(&query.Page, &query.PageSize)).
 		Preload("Project").
 		Preload("Group").
+		Preload("StatusInfo").
 		Preload("Member.UserInfo").
 		Order("status ASC").Order("level DESC").Order("create_time DESC").
 		Find(&list).Error
@@ -131,6 +143,7 @@ func (r *TaskRepo) Detail(id uint) (*repo.Task, error) {
 	var task *repo.Task
 	err := r.tx.Preload("Project").
 		Preload("Group").
+		Preload("StatusInfo").
 		Preload("Member.UserInfo").
 		Order("status ASC").Order("level DESC").Order("create_time DESC").
 		First(&task, id).Error
@@ -138,6 +151,31 @@ func (r *TaskRepo) Detail(id uint) (*repo.Task, error) {
 	return task, err
 }
 
+func (r *TaskRepo) GetChildren(parentIds []uint) ([]repo.Task, error) {
+	var list []repo.Task
+	if len(parentIds) <= 0 {
+		return list, nil
+	}
+	err := r.tx.Where("parent_id IN ?", parentIds).
+		Preload("Group").
+		Preload("StatusInfo").
+		Preload("Member.UserInfo").
+		Order("status ASC").Order("level DESC").Order("create_time DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *TaskRepo) UpdateFieldsByIds(ids []uint, values interface{}) error {
+	if len(ids) <= 0 {
+		return nil
+	}
+	return r.tx.Model(&repo.Task{}).Where("id IN ?", ids).Updates(values).Error
+}
+
+func (r *TaskRepo) ExistStatus(statusId uint) bool {
+	return r.tx.Select("id").Where("status_id = ?", statusId).First(&repo.Task{}).Error == nil
+}
+
 func (r *TaskRepo) TaskNumber(projectId uint, status []int) (int64, error) {
 	var count int64
 
@@ -167,7 +205,7 @@ func (r *TaskRepo) GetTasksByProject(projectId uint, status []int) ([]repo.Task,
 func (r *TaskRepo) CompletedQuantity(projectId uint, completeTime []int64) (int64, error) {
 	var count int64
 
-	tx := r.tx.Model(&repo.Task{}).Where(&repo.Task{ProjectId: projectId}).Where("status IN ?", []int{constant.TaskStatusCompleted, constant.TaskStatusArchived})
+	tx := r.tx.Model(&repo.Task{}).Where(&repo.Task{ProjectId: projectId}).Where("status IN ?", constant.GetTaskFinishedCategories())
 	if len(completeTime) >= 2 {
 		tx = tx.Where("complete_date BETWEEN ? AND ?", completeTime[0], completeTime[1])
 	}
@@ -0,0 +1,46 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskCommentRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskCommentRepo) Create(data *repo.TaskComment) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *TaskCommentRepo) Get(id uint) (*repo.TaskComment, error) {
+	var d *repo.TaskComment
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *TaskCommentRepo) UpdateFields(id uint, values interface{}) error {
+	return r.tx.Model(&repo.TaskComment{}).Where("id = ?", id).Updates(values).Error
+}
+
+func (r *TaskCommentRepo) GetTaskComments(taskId uint) ([]repo.TaskComment, error) {
+	var l []repo.TaskComment
+	err := r.tx.Where("task_id = ?", taskId).
+		Preload("CreatorInfo").
+		Order("create_time ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskCommentRepo) DeleteByTask(taskId uint) error {
+	return r.tx.Where("task_id = ?", taskId).Delete(&repo.TaskComment{}).Error
+}
+
+func NewTaskCommentRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskCommentRepo {
+	return &TaskCommentRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,37 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskCommentHistoryRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskCommentHistoryRepo) Create(data *repo.TaskCommentHistory) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *TaskCommentHistoryRepo) GetCommentHistories(commentId uint) ([]repo.TaskCommentHistory, error) {
+	var l []repo.TaskCommentHistory
+	err := r.tx.Where("comment_id = ?", commentId).
+		Preload("OperatorInfo").
+		Order("create_time DESC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskCommentHistoryRepo) DeleteByTask(taskId uint) error {
+	commentIds := r.tx.Model(&repo.TaskComment{}).Select("id").Where("task_id = ?", taskId)
+	return r.tx.Where("comment_id IN (?)", commentIds).Delete(&repo.TaskCommentHistory{}).Error
+}
+
+func NewTaskCommentHistoryRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskCommentHistoryRepo {
+	return &TaskCommentHistoryRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskDependencyRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskDependencyRepo) Create(data *repo.TaskDependency) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *TaskDependencyRepo) Delete(id uint) error {
+	return r.tx.Delete(&repo.TaskDependency{}, id).Error
+}
+
+func (r *TaskDependencyRepo) Get(id uint) (*repo.TaskDependency, error) {
+	var d *repo.TaskDependency
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *TaskDependencyRepo) Exist(taskId, dependId uint) bool {
+	return r.tx.Select("id").Where("task_id = ? AND depend_id = ?", taskId, dependId).First(&repo.TaskDependency{}).Error == nil
+}
+
+func (r *TaskDependencyRepo) GetBlockers(taskId uint) ([]repo.TaskDependency, error) {
+	var l []repo.TaskDependency
+	err := r.tx.Where("task_id = ?", taskId).
+		Joins("Depend").
+		Order("create_time ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskDependencyRepo) GetDependents(taskId uint) ([]repo.TaskDependency, error) {
+	var l []repo.TaskDependency
+	err := r.tx.Where("depend_id = ?", taskId).
+		Joins("Task").
+		Order("create_time ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskDependencyRepo) GetDependIds(taskIds []uint) ([]uint, error) {
+	var ids []uint
+	if len(taskIds) <= 0 {
+		return ids, nil
+	}
+	err := r.tx.Model(&repo.TaskDependency{}).Where("task_id IN ?", taskIds).Distinct().Pluck("depend_id", &ids).Error
+	return ids, err
+}
+
+func (r *TaskDependencyRepo) DeleteByTask(taskId uint) error {
+	return r.tx.Where("task_id = ? OR depend_id = ?", taskId, taskId).Delete(&repo.TaskDependency{}).Error
+}
+
+func NewTaskDependencyRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskDependencyRepo {
+	return &TaskDependencyRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskFieldRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskFieldRepo) Create(data *repo.TaskField) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *TaskFieldRepo) Save(data *repo.TaskField) error {
+	return r.tx.Save(&data).Error
+}
+
+func (r *TaskFieldRepo) Delete(id uint) error {
+	return r.tx.Delete(&repo.TaskField{}, id).Error
+}
+
+func (r *TaskFieldRepo) Get(id uint) (*repo.TaskField, error) {
+	var d *repo.TaskField
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *TaskFieldRepo) GetProjectFields(projectId uint) ([]repo.TaskField, error) {
+	var l []repo.TaskField
+	err := r.tx.Where("project_id = ?", projectId).
+		Order("sort ASC").Order("id ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskFieldRepo) ExistName(projectId uint, name string, excludeId uint) bool {
+	tx := r.tx.Select("id").Where("project_id = ? AND name = ?", projectId, name)
+	if excludeId > 0 {
+		tx = tx.Where("id <> ?", excludeId)
+	}
+	return tx.First(&repo.TaskField{}).Error == nil
+}
+
+func NewTaskFieldRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskFieldRepo {
+	return &TaskFieldRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskFieldValueRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskFieldValueRepo) Save(data *repo.TaskFieldValue) error {
+	return r.tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"value", "update_time"}),
+	}).Create(&data).Error
+}
+
+func (r *TaskFieldValueRepo) GetTaskValues(taskId uint) ([]repo.TaskFieldValue, error) {
+	var l []repo.TaskFieldValue
+	err := r.tx.Where("task_id = ?", taskId).
+		Joins("Field").
+		Order("Field.sort ASC").Order("Field.id ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskFieldValueRepo) GetTaskIds(field *repo.TaskField, filter dto.TaskFieldFilter) ([]uint, error) {
+	var ids []uint
+	tx := r.tx.Model(&repo.TaskFieldValue{}).Where("field_id = ?", field.ID)
+
+	switch field.Type {
+	case constant.TaskFieldText:
+		tx = tx.Where("value LIKE ?", "%"+filter.Value+"%")
+	case constant.TaskFieldNumber, constant.TaskFieldDate:
+		// 数字按数值比较，日期格式固定，可以直接按字符串比较
+		column := "value"
+		if field.Type == constant.TaskFieldNumber {
+			column = "CAST(value AS DECIMAL(30,10))"
+		}
+		if len(filter.Value) > 0 {
+			tx = tx.Where(column+" = ?", filter.Value)
+		}
+		if len(filter.Range) >= 1 && len(filter.Range[0]) > 0 {
+			tx = tx.Where(column+" >= ?", filter.Range[0])
+		}
+		if len(filter.Range) >= 2 && len(filter.Range[1]) > 0 {
+			tx = tx.Where(column+" <= ?", filter.Range[1])
+		}
+	case constant.TaskFieldMultiSelect:
+		tx = tx.Where("JSON_CONTAINS(value, JSON_QUOTE(?))", filter.Value)
+	default:
+		tx = tx.Where("value = ?", filter.Value)
+	}
+
+	err := tx.Distinct().Pluck("task_id", &ids).Error
+	return ids, err
+}
+
+func (r *TaskFieldValueRepo) DeleteByFields(taskId uint, fieldIds []uint) error {
+	if len(fieldIds) <= 0 {
+		return nil
+	}
+	return r.tx.Where("task_id = ? AND field_id IN ?", taskId, fieldIds).Delete(&repo.TaskFieldValue{}).Error
+}
+
+func (r *TaskFieldValueRepo) DeleteByTask(taskId uint) error {
+	return r.tx.Where("task_id = ?", taskId).Delete(&repo.TaskFieldValue{}).Error
+}
+
+func (r *TaskFieldValueRepo) DeleteByField(fieldId uint) error {
+	return r.tx.Where("field_id = ?", fieldId).Delete(&repo.TaskFieldValue{}).Error
+}
+
+func NewTaskFieldValueRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskFieldValueRepo {
+	return &TaskFieldValueRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
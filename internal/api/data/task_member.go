@@ -92,6 +92,10 @@ func (r *TaskMemberRepo) GetTaskIdsByUsers(userIds []uint64, role []int) ([]uint
 	return taskIds, err
 }
 
+func (r *TaskMemberRepo) DeleteTaskAllMember(taskId uint) error {
+	return r.tx.Where("task_id = ?", taskId).Delete(&repo.TaskMember{}).Error
+}
+
 func NewTaskMemberRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskMemberRepo {
 	return &TaskMemberRepo{
 		tx:  tx,
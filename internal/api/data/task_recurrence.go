@@ -0,0 +1,54 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskRecurrenceRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskRecurrenceRepo) Save(data *repo.TaskRecurrence) error {
+	return r.tx.Save(&data).Error
+}
+
+func (r *TaskRecurrenceRepo) Delete(id uint) error {
+	return r.tx.Delete(&repo.TaskRecurrence{}, id).Error
+}
+
+func (r *TaskRecurrenceRepo) GetByTask(taskId uint) (*repo.TaskRecurrence, error) {
+	var d *repo.TaskRecurrence
+	err := r.tx.Where("task_id = ?", taskId).First(&d).Error
+	return d, err
+}
+
+func (r *TaskRecurrenceRepo) GetDue(now int64) ([]repo.TaskRecurrence, error) {
+	var l []repo.TaskRecurrence
+	err := r.tx.Where("next_time > 0 AND next_time <= ?", now).
+		Order("next_time ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskRecurrenceRepo) UpdateFields(id uint, values interface{}) error {
+	return r.tx.Model(&repo.TaskRecurrence{}).Where("id = ?", id).Updates(values).Error
+}
+
+func (r *TaskRecurrenceRepo) Claim(id uint, nextTime int64, values interface{}) (bool, error) {
+	result := r.tx.Model(&repo.TaskRecurrence{}).Where("id = ? AND next_time = ?", id, nextTime).Updates(values)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *TaskRecurrenceRepo) DeleteByTask(taskId uint) error {
+	return r.tx.Where("task_id = ?", taskId).Delete(&repo.TaskRecurrence{}).Error
+}
+
+func NewTaskRecurrenceRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskRecurrenceRepo {
+	return &TaskRecurrenceRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaskStatusRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func (r *TaskStatusRepo) Create(data *repo.TaskStatus) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *TaskStatusRepo) Save(data *repo.TaskStatus) error {
+	return r.tx.Save(&data).Error
+}
+
+func (r *TaskStatusRepo) Delete(id uint) error {
+	return r.tx.Delete(&repo.TaskStatus{}, id).Error
+}
+
+func (r *TaskStatusRepo) Get(id uint) (*repo.TaskStatus, error) {
+	var d *repo.TaskStatus
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *TaskStatusRepo) GetProjectStatuses(projectId uint) ([]repo.TaskStatus, error) {
+	var l []repo.TaskStatus
+	err := r.tx.Where("project_id = ?", projectId).
+		Order("sort ASC").Order("id ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *TaskStatusRepo) GetFirstByCategory(projectId uint, category int) (*repo.TaskStatus, error) {
+	var d *repo.TaskStatus
+	err := r.tx.Where("project_id = ? AND category = ?", projectId, category).
+		Order("sort ASC").Order("id ASC").
+		First(&d).Error
+	return d, err
+}
+
+func (r *TaskStatusRepo) GetDefault(projectId uint) (*repo.TaskStatus, error) {
+	var d *repo.TaskStatus
+	err := r.tx.Where("project_id = ? AND category = ?", projectId, constant.TaskCategoryOpen).
+		Order("is_default DESC").Order("sort ASC").Order("id ASC").
+		First(&d).Error
+	return d, err
+}
+
+func (r *TaskStatusRepo) ExistName(projectId uint, name string, excludeId uint) bool {
+	tx := r.tx.Select("id").Where("project_id = ? AND name = ?", projectId, name)
+	if excludeId > 0 {
+		tx = tx.Where("id <> ?", excludeId)
+	}
+	return tx.First(&repo.TaskStatus{}).Error == nil
+}
+
+func (r *TaskStatusRepo) ClearDefault(projectId uint, excludeId uint) error {
+	return r.tx.Model(&repo.TaskStatus{}).
+		Where("project_id = ? AND id <> ?", projectId, excludeId).
+		Update("is_default", 0).Error
+}
+
+func NewTaskStatusRepo(tx *gorm.DB, ctx *gin.Context) repo.TaskStatusRepo {
+	return &TaskStatusRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
@@ -134,6 +134,15 @@ func (r *UserRepo) GetAdministrators() ([]repo.User, error) {
 	return l, err
 }
 
+func (r *UserRepo) GetUsers(ids []uint64) ([]repo.User, error) {
+	var l []repo.User
+	if len(ids) <= 0 {
+		return l, nil
+	}
+	err := r.tx.Where("id IN ?", ids).Where("user_status = ?", 1).Find(&l).Error
+	return l, err
+}
+
 func NewUserRepo(tx *gorm.DB, ctx *gin.Context) repo.UserRepo {
 	return &UserRepo{
 		tx:  tx,
@@ -9,6 +9,7 @@ import (
 	"VitaTaskGo/pkg/time_tool"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -93,6 +94,38 @@ func (r *WorkflowRepo) PageList(query dto.WorkflowListQueryDto) ([]repo.Workflow
 		tx = tx.Where("serials LIKE ?", "%"+query.Serials+"%")
 	}
 
+	if query.ProjectId > 0 {
+		tx = tx.Where("project_id = ?", query.ProjectId)
+	}
+
+	if query.TaskId > 0 {
+		tx = tx.Where("task_id = ?", query.TaskId)
+	}
+
+	if query.Promoter > 0 {
+		tx = tx.Where("promoter = ?", query.Promoter)
+	}
+
+	// 待办，当前节点或进行中的并行分支有该用户未处理的操作人记录
+	if query.TodoUser > 0 {
+		workflowTable := (&repo.Workflow{}).TableName()
+		tx = tx.Where(
+			"EXISTS (SELECT 1 FROM "+(&repo.WorkflowOperator{}).TableName()+" AS o WHERE o.workflow_id = "+
+				workflowTable+".id AND o.user_id = ? AND o.handled = 0 AND (o.node = "+workflowTable+".node OR EXISTS ("+
+				"SELECT 1 FROM "+(&repo.WorkflowToken{}).TableName()+" AS t WHERE t.workflow_id = o.workflow_id AND t.node = o.node AND t.waiting = 0)))",
+			query.TodoUser,
+		)
+	}
+
+	// 已办，该用户有审批日志
+	if query.HandledUser > 0 {
+		tx = tx.Where(
+			"id IN (SELECT workflow_id FROM "+(&repo.WorkflowLog{}).TableName()+" WHERE operator = ? AND action IN ?)",
+			query.HandledUser,
+			query.HandledActions,
+		)
+	}
+
 	// 查询已删除的记录
 	if query.Deleted {
 		tx = tx.Unscoped().Where("deleted_at IS NOT NULL")
@@ -108,18 +141,41 @@ func (r *WorkflowRepo) PageList(query dto.WorkflowListQueryDto) ([]repo.Workflow
 	err = tx.Scopes(db.Paginate(&query.Page, &query.PageSize)).
 		// 关联当前操作人
 		Preload("Operator").
+		// 关联并行分支
+		Preload("Tokens").
 		Order("create_time DESC").
 		Find(&list).Error
 
 	return list, total, exception.ErrorHandle(err, response.DbQueryError)
 }
 
-func (r *WorkflowRepo) GetDayTotal(start, end int64) (int64, error) {
-	var count int64
-	err := r.tx.Model(&repo.Workflow{}).Where("create_time BETWEEN ? AND ?", start, end).Count(&count).Error
-	return count, err
-}
-
 func (r *WorkflowRepo) SetDbInstance(tx *gorm.DB) {
 	r.tx = tx
 }
+
+func (r *WorkflowRepo) Lock(id uint) error {
+	var d *repo.Workflow
+	return r.tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&d, id).Error
+}
+
+func (r *WorkflowRepo) CountOutdated(typeId uint, version int, status []int) (int64, error) {
+	var total int64
+	err := r.tx.Model(&repo.Workflow{}).
+		Where("type_id = ? AND version < ?", typeId, version).
+		Where("status IN ?", status).
+		Count(&total).Error
+	return total, err
+}
+
+func (r *WorkflowRepo) GetTaskWorkflows(taskId uint) ([]repo.Workflow, error) {
+	var list []repo.Workflow
+	err := r.tx.Where("task_id = ?", taskId).Order("create_time DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *WorkflowRepo) ExistTaskWorkflow(taskId uint, typeId uint, status int) bool {
+	// 有记录就说明查到了
+	return r.tx.Select("id").
+		Where("task_id = ? AND type_id = ? AND status = ?", taskId, typeId, status).
+		First(&repo.Workflow{}).Error == nil
+}
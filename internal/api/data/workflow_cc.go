@@ -0,0 +1,84 @@
+package data
+
+import (
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkflowCcRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowCcRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowCcRepo {
+	return &WorkflowCcRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowCcRepo) Save(list []repo.WorkflowCc) error {
+	if len(list) <= 0 {
+		return nil
+	}
+	return r.tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"node_name", "nickname", "read_time", "update_time"}),
+	}).Create(&list).Error
+}
+
+func (r *WorkflowCcRepo) PageList(query dto.WorkflowCcQueryDto) ([]repo.WorkflowCc, int64, error) {
+	var (
+		list  []repo.WorkflowCc
+		total int64
+	)
+
+	tx := r.tx.Model(&repo.WorkflowCc{}).Where("user_id = ?", query.UserId)
+	if query.WorkflowId > 0 {
+		tx = tx.Where("workflow_id = ?", query.WorkflowId)
+	}
+	switch query.Read {
+	case dto.WorkflowCcRead:
+		tx = tx.Where("read_time > 0")
+	case dto.WorkflowCcUnread:
+		tx = tx.Where("read_time = 0")
+	}
+
+	err := tx.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = tx.Scopes(db.Paginate(&query.Page, &query.PageSize)).
+		Preload("Workflow").
+		Order("update_time DESC").
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *WorkflowCcRepo) MarkRead(userId uint64, ids []uint, t int64) error {
+	tx := r.tx.Model(&repo.WorkflowCc{}).Where("user_id = ? AND read_time = 0", userId)
+	if len(ids) > 0 {
+		tx = tx.Where("id IN ?", ids)
+	}
+	return tx.Update("read_time", t).Error
+}
+
+func (r *WorkflowCcRepo) UnreadCount(userId uint64) (int64, error) {
+	var total int64
+	err := r.tx.Model(&repo.WorkflowCc{}).Where("user_id = ? AND read_time = 0", userId).Count(&total).Error
+	return total, err
+}
+
+func (r *WorkflowCcRepo) Exist(workflowId uint, userId uint64) bool {
+	return r.tx.Select("id").
+		Where("workflow_id = ? AND user_id = ?", workflowId, userId).
+		First(&repo.WorkflowCc{}).Error == nil
+}
+
+func (r *WorkflowCcRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
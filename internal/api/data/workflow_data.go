@@ -0,0 +1,51 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowDataRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowDataRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowDataRepo {
+	return &WorkflowDataRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowDataRepo) Create(data *repo.WorkflowData) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *WorkflowDataRepo) Get(id uint) (*repo.WorkflowData, error) {
+	var d *repo.WorkflowData
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *WorkflowDataRepo) GetLatest(workflowId uint) (*repo.WorkflowData, error) {
+	var d *repo.WorkflowData
+	err := r.tx.Model(&repo.WorkflowData{}).
+		Where(&repo.WorkflowData{WorkflowId: workflowId}).
+		Order("version DESC").
+		First(&d).Error
+	return d, err
+}
+
+func (r *WorkflowDataRepo) GetVersions(workflowId uint) ([]repo.WorkflowData, error) {
+	var l []repo.WorkflowData
+	err := r.tx.Model(&repo.WorkflowData{}).
+		Where(&repo.WorkflowData{WorkflowId: workflowId}).
+		Order("version DESC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *WorkflowDataRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
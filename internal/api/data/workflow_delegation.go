@@ -0,0 +1,55 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowDelegationRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowDelegationRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowDelegationRepo {
+	return &WorkflowDelegationRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowDelegationRepo) Create(data *repo.WorkflowDelegation) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *WorkflowDelegationRepo) Save(data *repo.WorkflowDelegation) error {
+	return r.tx.Save(&data).Error
+}
+
+func (r *WorkflowDelegationRepo) Delete(id uint) error {
+	return r.tx.Delete(&repo.WorkflowDelegation{}, id).Error
+}
+
+func (r *WorkflowDelegationRepo) Get(id uint) (*repo.WorkflowDelegation, error) {
+	var d *repo.WorkflowDelegation
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *WorkflowDelegationRepo) GetUserDelegations(userId uint64) ([]repo.WorkflowDelegation, error) {
+	var list []repo.WorkflowDelegation
+	err := r.tx.Where("user_id = ?", userId).Order("start_time DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *WorkflowDelegationRepo) GetActive(userId uint64, at int64) ([]repo.WorkflowDelegation, error) {
+	var list []repo.WorkflowDelegation
+	err := r.tx.Where("user_id = ? AND start_time <= ? AND end_time >= ?", userId, at, at).
+		Order("create_time DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *WorkflowDelegationRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
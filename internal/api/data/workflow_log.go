@@ -0,0 +1,50 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowLogRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowLogRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowLogRepo {
+	return &WorkflowLogRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowLogRepo) Create(data *repo.WorkflowLog) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *WorkflowLogRepo) Get(id uint) (*repo.WorkflowLog, error) {
+	var d *repo.WorkflowLog
+	err := r.tx.First(&d, id).Error
+	return d, err
+}
+
+func (r *WorkflowLogRepo) GetTimeline(workflowId uint) ([]repo.WorkflowLog, error) {
+	var l []repo.WorkflowLog
+	err := r.tx.Model(&repo.WorkflowLog{}).
+		Where(&repo.WorkflowLog{WorkflowId: workflowId}).
+		Preload("OperatorInfo").
+		Order("operate_time ASC").Order("id ASC").
+		Find(&l).Error
+	return l, err
+}
+
+func (r *WorkflowLogRepo) ExistOperator(workflowId uint, userId uint64) bool {
+	return r.tx.Select("id").
+		Where("workflow_id = ?", workflowId).
+		Where("operator = ? OR on_behalf_of = ?", userId, userId).
+		First(&repo.WorkflowLog{}).Error == nil
+}
+
+func (r *WorkflowLogRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
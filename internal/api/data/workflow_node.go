@@ -125,3 +125,9 @@ func (r *WorkflowNodeRepo) FirstNode(typeId uint) (*repo.WorkflowNode, error) {
 func (r *WorkflowNodeRepo) SetDbInstance(tx *gorm.DB) {
 	r.tx = tx
 }
+
+func (r *WorkflowNodeRepo) GetTypeNodes(typeId uint) ([]repo.WorkflowNode, error) {
+	var list []repo.WorkflowNode
+	err := r.tx.Where(&repo.WorkflowNode{TypeId: typeId}).Order("node ASC").Find(&list).Error
+	return list, err
+}
@@ -74,3 +74,34 @@ func (r *WorkflowOperatorRepo) SetHandled(workflowId uint, node int, userId uint
 		Where(&repo.WorkflowOperator{WorkflowId: workflowId, Node: node, UserId: userId}).
 		Update("handled", 1).Error
 }
+
+func (r *WorkflowOperatorRepo) SetVote(workflowId uint, node int, userId uint64, vote string, t int64) error {
+	return r.tx.Model(&repo.WorkflowOperator{}).
+		Where(&repo.WorkflowOperator{WorkflowId: workflowId, Node: node, UserId: userId}).
+		Updates(map[string]interface{}{"handled": 1, "vote": vote, "vote_time": t}).Error
+}
+
+func (r *WorkflowOperatorRepo) SetTimeout(ids []uint, t int64, deadline int64) error {
+	return r.tx.Model(&repo.WorkflowOperator{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"timeout_time": t, "deadline": deadline}).Error
+}
+
+func (r *WorkflowOperatorRepo) GetTimeoutWorkflowIds(status int, now int64) ([]uint, error) {
+	var ids []uint
+	err := r.tx.Table((&repo.WorkflowOperator{}).TableName()+" AS o").
+		Joins("JOIN "+(&repo.Workflow{}).TableName()+" AS w ON w.id = o.workflow_id").
+		Where("w.status = ? AND w.deleted_at IS NULL", status).
+		Where("(w.node = o.node OR EXISTS (SELECT 1 FROM "+(&repo.WorkflowToken{}).TableName()+
+			" AS t WHERE t.workflow_id = o.workflow_id AND t.node = o.node AND t.waiting = 0))").
+		Where("o.handled = 0 AND o.deadline > 0 AND o.deadline <= ?", now).
+		Distinct().
+		Pluck("o.workflow_id", &ids).Error
+	return ids, err
+}
+
+func (r *WorkflowOperatorRepo) ExistOperator(workflowId uint, userId uint64) bool {
+	return r.tx.Select("id").
+		Where(&repo.WorkflowOperator{WorkflowId: workflowId, UserId: userId}).
+		First(&repo.WorkflowOperator{}).Error == nil
+}
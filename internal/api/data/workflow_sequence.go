@@ -0,0 +1,46 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkflowSequenceRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowSequenceRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowSequenceRepo {
+	return &WorkflowSequenceRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowSequenceRepo) Next(scope, date string) (int64, error) {
+	// 确保记录存在，并发插入时由唯一索引去重
+	err := r.tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&repo.WorkflowSequence{Scope: scope, Date: date}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	// 锁定该行直到事务结束
+	var sequence *repo.WorkflowSequence
+	err = r.tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND date = ?", scope, date).
+		First(&sequence).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sequence.Value += 1
+	err = r.tx.Model(&repo.WorkflowSequence{}).Where("id = ?", sequence.ID).Update("value", sequence.Value).Error
+	return sequence.Value, err
+}
+
+func (r *WorkflowSequenceRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowTokenRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowTokenRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowTokenRepo {
+	return &WorkflowTokenRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowTokenRepo) GetWorkflowTokens(workflowId uint) ([]repo.WorkflowToken, error) {
+	var list []repo.WorkflowToken
+	err := r.tx.Where("workflow_id = ?", workflowId).Order("id").Find(&list).Error
+	return list, err
+}
+
+func (r *WorkflowTokenRepo) Replace(workflowId uint, tokens []repo.WorkflowToken) error {
+	err := r.tx.Where("workflow_id = ?", workflowId).Delete(&repo.WorkflowToken{}).Error
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) <= 0 {
+		return nil
+	}
+	for i := range tokens {
+		tokens[i].ID = 0
+		tokens[i].WorkflowId = workflowId
+	}
+	return r.tx.Create(&tokens).Error
+}
+
+func (r *WorkflowTokenRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
@@ -126,6 +126,12 @@ func (r *WorkflowTypeRepo) GetNotSystemIds() ([]uint, error) {
 	return ids, err
 }
 
+func (r *WorkflowTypeRepo) GetTaskGates(status int) ([]repo.WorkflowType, error) {
+	var list []repo.WorkflowType
+	err := r.tx.Where("task_gate_status = ?", status).Find(&list).Error
+	return list, err
+}
+
 func (r *WorkflowTypeRepo) ExistByOnlyName(onlyName string) bool {
 	// 有记录就说明查到了
 	return r.tx.Select("id").Where(&repo.WorkflowType{OnlyName: onlyName}).First(&repo.WorkflowType{}).Error == nil
@@ -0,0 +1,48 @@
+package data
+
+import (
+	"VitaTaskGo/internal/repo"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WorkflowTypeVersionRepo struct {
+	tx  *gorm.DB
+	ctx *gin.Context
+}
+
+func NewWorkflowTypeVersionRepo(tx *gorm.DB, ctx *gin.Context) repo.WorkflowTypeVersionRepo {
+	return &WorkflowTypeVersionRepo{
+		tx:  tx,
+		ctx: ctx,
+	}
+}
+
+func (r *WorkflowTypeVersionRepo) Create(data *repo.WorkflowTypeVersion) error {
+	return r.tx.Create(&data).Error
+}
+
+func (r *WorkflowTypeVersionRepo) GetVersion(typeId uint, version int) (*repo.WorkflowTypeVersion, error) {
+	var d *repo.WorkflowTypeVersion
+	err := r.tx.Where("type_id = ? AND version = ?", typeId, version).First(&d).Error
+	return d, err
+}
+
+func (r *WorkflowTypeVersionRepo) GetVersions(typeId uint) ([]repo.WorkflowTypeVersion, error) {
+	var list []repo.WorkflowTypeVersion
+	err := r.tx.Omit("snapshot").Where("type_id = ?", typeId).Order("version DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *WorkflowTypeVersionRepo) GetLatestVersion(typeId uint) (int, error) {
+	var version int
+	err := r.tx.Model(&repo.WorkflowTypeVersion{}).
+		Where("type_id = ?", typeId).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+func (r *WorkflowTypeVersionRepo) SetDbInstance(tx *gorm.DB) {
+	r.tx = tx
+}
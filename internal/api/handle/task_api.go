@@ -64,9 +64,12 @@ func (receiver TaskApi) Roles(ctx *gin.Context) {
 }
 
 func (receiver TaskApi) Status(ctx *gin.Context) {
+	// 从Query中获取项目id，为空时返回默认状态
+	projectId := pkg.ParseStringToUi64(ctx.Query("project"))
+
 	ctx.JSON(
 		http.StatusOK,
-		response.SuccessData(service.NewTaskService(db.Db, ctx).Status()),
+		response.SuccessData(service.NewTaskService(db.Db, ctx).Status(uint(projectId))),
 	)
 }
 
@@ -79,7 +82,7 @@ func (receiver TaskApi) ChangeStatus(ctx *gin.Context) {
 
 	ctx.JSON(
 		http.StatusOK,
-		response.Auto(nil, service.NewTaskService(db.Db, ctx).ChangeStatus(post.ID, post.Status)),
+		response.Auto(nil, service.NewTaskService(db.Db, ctx).ChangeStatus(post)),
 	)
 }
 
@@ -136,3 +139,244 @@ func (receiver TaskApi) DailySituation(ctx *gin.Context) {
 		response.Auto(service.NewTaskService(db.Db, ctx).DailySituation(post)),
 	)
 }
+
+func (receiver TaskApi) DependencyAdd(ctx *gin.Context) {
+	var post dto.TaskDependencyForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskDependencyService(db.Db, ctx).Add(post)),
+	)
+}
+
+func (receiver TaskApi) DependencyDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewTaskDependencyService(db.Db, ctx).Delete(post.ID)),
+	)
+}
+
+func (receiver TaskApi) DependencyList(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskDependencyService(db.Db, ctx).List(post.ID)),
+	)
+}
+
+func (receiver TaskApi) RecurrenceSet(ctx *gin.Context) {
+	var post dto.TaskRecurrenceForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskRecurrenceService(db.Db, ctx).Set(post)),
+	)
+}
+
+func (receiver TaskApi) RecurrenceDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewTaskRecurrenceService(db.Db, ctx).Delete(post.ID)),
+	)
+}
+
+func (receiver TaskApi) RecurrenceDetail(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskRecurrenceService(db.Db, ctx).Detail(post.ID)),
+	)
+}
+
+func (receiver TaskApi) FieldAdd(ctx *gin.Context) {
+	var post dto.TaskFieldForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskFieldService(db.Db, ctx).Add(post)),
+	)
+}
+
+func (receiver TaskApi) FieldUpdate(ctx *gin.Context) {
+	var post dto.TaskFieldForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskFieldService(db.Db, ctx).Update(post)),
+	)
+}
+
+func (receiver TaskApi) FieldDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewTaskFieldService(db.Db, ctx).Delete(post.ID)),
+	)
+}
+
+func (receiver TaskApi) FieldList(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskFieldService(db.Db, ctx).List(post.ID)),
+	)
+}
+
+func (receiver TaskApi) FieldTypes(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.SuccessData(service.NewTaskFieldService(db.Db, ctx).Types()),
+	)
+}
+
+func (receiver TaskApi) StatusConfigSave(ctx *gin.Context) {
+	var post dto.TaskStatusForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskStatusService(db.Db, ctx).Save(post)),
+	)
+}
+
+func (receiver TaskApi) StatusConfigDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewTaskStatusService(db.Db, ctx).Delete(post.ID)),
+	)
+}
+
+func (receiver TaskApi) StatusConfigList(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskStatusService(db.Db, ctx).List(post.ID)),
+	)
+}
+
+func (receiver TaskApi) CommentAdd(ctx *gin.Context) {
+	var post dto.TaskCommentForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskCommentService(db.Db, ctx).Add(post)),
+	)
+}
+
+func (receiver TaskApi) CommentUpdate(ctx *gin.Context) {
+	var post dto.TaskCommentForm
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskCommentService(db.Db, ctx).Update(post)),
+	)
+}
+
+func (receiver TaskApi) CommentDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewTaskCommentService(db.Db, ctx).Delete(post.ID)),
+	)
+}
+
+func (receiver TaskApi) CommentList(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskCommentService(db.Db, ctx).List(post.ID)),
+	)
+}
+
+func (receiver TaskApi) CommentHistory(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewTaskCommentService(db.Db, ctx).History(post.ID)),
+	)
+}
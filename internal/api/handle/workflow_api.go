@@ -42,6 +42,126 @@ func (r WorkflowApi) ExamineApprove(ctx *gin.Context) {
 	)
 }
 
+// Withdraw 撤回工作流
+func (r WorkflowApi) Withdraw(ctx *gin.Context) {
+	var post dto.WorkflowWithdrawDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewWorkflowService(db.Db, ctx).Withdraw(post)),
+	)
+}
+
+// DelegationList 当前用户的委托规则
+func (r WorkflowApi) DelegationList(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).DelegationList()),
+	)
+}
+
+// DelegationAdd 添加委托规则
+func (r WorkflowApi) DelegationAdd(ctx *gin.Context) {
+	var post dto.WorkflowDelegationDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).DelegationAdd(post)),
+	)
+}
+
+// DelegationUpdate 修改委托规则
+func (r WorkflowApi) DelegationUpdate(ctx *gin.Context) {
+	var post dto.WorkflowDelegationDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).DelegationUpdate(post)),
+	)
+}
+
+// DelegationDelete 删除委托规则
+func (r WorkflowApi) DelegationDelete(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewWorkflowService(db.Db, ctx).DelegationDelete(post.ID)),
+	)
+}
+
+// Data 工作流附加数据
+func (r WorkflowApi) Data(ctx *gin.Context) {
+	var post dto.WorkflowIdDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).Data(post.WorkflowId)),
+	)
+}
+
+// DataVersions 工作流附加数据的所有版本
+func (r WorkflowApi) DataVersions(ctx *gin.Context) {
+	var post dto.WorkflowIdDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).DataVersions(post.WorkflowId)),
+	)
+}
+
+// DataUpdate 修改工作流附加数据
+func (r WorkflowApi) DataUpdate(ctx *gin.Context) {
+	var post dto.WorkflowDataUpdateDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewWorkflowService(db.Db, ctx).DataUpdate(post)),
+	)
+}
+
+// Log 工作流日志时间线
+func (r WorkflowApi) Log(ctx *gin.Context) {
+	var post dto.WorkflowIdDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).Log(post.WorkflowId)),
+	)
+}
+
 func (r WorkflowApi) All(ctx *gin.Context) {
 	var query dto.WorkflowListQueryDto
 	if err := ctx.ShouldBindJSON(&query); err != nil {
@@ -70,7 +190,7 @@ func (r WorkflowApi) ToDo(ctx *gin.Context) {
 
 	ctx.JSON(
 		http.StatusOK,
-		response.Auto(service.NewWorkflowService(db.Db, ctx).PageList(query)),
+		response.Auto(service.NewWorkflowService(db.Db, ctx).ToDo(query)),
 	)
 }
 
@@ -87,7 +207,43 @@ func (r WorkflowApi) Handled(ctx *gin.Context) {
 
 	ctx.JSON(
 		http.StatusOK,
-		response.Auto(service.NewWorkflowService(db.Db, ctx).PageList(query)),
+		response.Auto(service.NewWorkflowService(db.Db, ctx).Handled(query)),
+	)
+}
+
+// Cc 抄送给我的工作流分页列表
+func (r WorkflowApi) Cc(ctx *gin.Context) {
+	var query dto.WorkflowCcQueryDto
+	if err := ctx.ShouldBindJSON(&query); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).CcList(query)),
+	)
+}
+
+// CcRead 抄送标记为已读
+func (r WorkflowApi) CcRead(ctx *gin.Context) {
+	var post dto.WorkflowCcReadDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(nil, service.NewWorkflowService(db.Db, ctx).CcRead(post)),
+	)
+}
+
+// CcUnread 未读的抄送数量
+func (r WorkflowApi) CcUnread(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).CcUnread()),
 	)
 }
 
@@ -148,6 +304,48 @@ func (r WorkflowApi) TypeList(ctx *gin.Context) {
 	)
 }
 
+// Simulate 模拟工作流流转
+func (r WorkflowApi) Simulate(ctx *gin.Context) {
+	var post dto.WorkflowSimulateDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).Simulate(post)),
+	)
+}
+
+// TypeExport 导出工作流定义
+func (r WorkflowApi) TypeExport(ctx *gin.Context) {
+	var post dto.WorkflowDefinitionExportDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypeExport(post)),
+	)
+}
+
+// TypeImport 导入工作流定义
+func (r WorkflowApi) TypeImport(ctx *gin.Context) {
+	var post dto.WorkflowDefinitionImportDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypeImport(post)),
+	)
+}
+
 func (r WorkflowApi) TypeDelete(ctx *gin.Context) {
 	var post dto.SingleUintRequired
 	if err := ctx.ShouldBindJSON(&post); err != nil {
@@ -174,6 +372,61 @@ func (r WorkflowApi) TypeDetail(ctx *gin.Context) {
 	)
 }
 
+// TypePublish 发布工作流定义
+func (r WorkflowApi) TypePublish(ctx *gin.Context) {
+	var post dto.WorkflowTypePublishDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypePublish(post)),
+	)
+}
+
+// TypeVersions 工作流定义的版本列表
+func (r WorkflowApi) TypeVersions(ctx *gin.Context) {
+	var post dto.SingleUintRequired
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypeVersions(post.ID)),
+	)
+}
+
+// TypeVersionDetail 工作流定义指定版本的快照
+func (r WorkflowApi) TypeVersionDetail(ctx *gin.Context) {
+	var post dto.WorkflowTypeVersionDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypeVersionDetail(post)),
+	)
+}
+
+func (r WorkflowApi) TypeSchema(ctx *gin.Context) {
+	var post dto.WorkflowTypeOnlyNameDto
+	if err := ctx.ShouldBindJSON(&post); err != nil {
+		ctx.JSON(http.StatusOK, response.HandleFormVerificationFailed(err))
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		response.Auto(service.NewWorkflowService(db.Db, ctx).TypeSchema(post.OnlyName)),
+	)
+}
+
 func (r WorkflowApi) TypeOptions(ctx *gin.Context) {
 	keyWords := ctx.DefaultQuery("keyWords", "")
 	system := ctx.DefaultQuery("system", "")
@@ -251,6 +504,27 @@ func (r WorkflowApi) Actions(ctx *gin.Context) {
 	)
 }
 
+func (r WorkflowApi) ApproveModes(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.SuccessData(service.NewWorkflowService(db.Db, ctx).ApproveModes()),
+	)
+}
+
+func (r WorkflowApi) JoinModes(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.SuccessData(service.NewWorkflowService(db.Db, ctx).JoinModes()),
+	)
+}
+
+func (r WorkflowApi) TimeoutActions(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		response.SuccessData(service.NewWorkflowService(db.Db, ctx).TimeoutActions()),
+	)
+}
+
 func (r WorkflowApi) StatusList(ctx *gin.Context) {
 	ctx.JSON(
 		http.StatusOK,
@@ -11,6 +11,16 @@ type TaskListQuery struct {
 	Leader       uint64   `json:"leader"`       // 负责人
 	Collaborator []uint64 `json:"collaborator"` // 协助人
 	GroupId      uint     `json:"group"`
+	ParentId     uint     `json:"parent_id"` // 父任务ID，只查询该任务的直接子任务
+	TopLevel     bool     `json:"top_level"` // 只查询顶级任务，子任务在 children 中返回
+	// 自定义字段筛选，多个条件同时满足
+	Fields []TaskFieldFilter `json:"fields"`
+}
+
+type TaskFieldFilter struct {
+	FieldId uint     `json:"field_id"`
+	Value   string   `json:"value"` // 文本为模糊匹配，多选为包含该选项，其它类型为精确匹配
+	Range   []string `json:"range"` // 数字与日期的范围，最小值与最大值，可以只提供一端
 }
 
 type TaskListQueryBO struct {
@@ -24,28 +34,81 @@ type TaskListQueryBO struct {
 	LeaderTaskIds       []uint
 	CollaboratorTaskIds []uint
 	GroupId             uint
+	ParentId            uint
+	TopLevel            bool
+	FieldTaskIds        []uint
 }
 
 type TaskCreateForm struct {
 	ProjectId    uint     `json:"project,omitempty" binding:"required"`
 	GroupId      uint     `json:"group,omitempty"`
+	ParentId     uint     `json:"parent_id,omitempty"` // 父任务ID
 	Title        string   `json:"title,omitempty" binding:"required"`
 	Describe     string   `json:"describe,omitempty"`
 	Level        uint     `json:"level,omitempty"`
 	PlanTime     []string `json:"plan_time"`
 	Leader       uint64   `json:"leader" binding:"required"` // 负责人
 	Collaborator []uint64 `json:"collaborator"`              // 协助人
+	// 自定义字段值，键为字段ID，值为空时清除该字段
+	Fields map[uint]interface{} `json:"fields"`
 }
 
 type TaskStatusVo struct {
 	Label  string `json:"label"`
-	Value  int    `json:"value"`
+	Value  int    `json:"value"` // 状态分类
 	Status string `json:"status"`
+	// 项目自定义的状态
+	Id          uint   `json:"id,omitempty"`
+	Color       string `json:"color,omitempty"`
+	Transitions string `json:"transitions,omitempty"`
 }
 
 type TaskChangeStatus struct {
 	SingleUintRequired
-	Status int `json:"status"`
+	Status   int  `json:"status"`    // 状态分类，项目有自定义状态时使用该分类排序最靠前的状态
+	StatusId uint `json:"status_id"` // 项目自定义的状态，优先于 Status
+	Force    bool `json:"force"`     // 前置任务未完成时是否仍然修改
+}
+
+type TaskStatusForm struct {
+	UintId
+	ProjectId   uint   `json:"project" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Category    int    `json:"category"` // 状态分类 0-未完成 1-已完成 2-已归档
+	Color       string `json:"color"`
+	Sort        int    `json:"sort"`
+	IsDefault   bool   `json:"is_default"`  // 是否为新任务的初始状态
+	Transitions []uint `json:"transitions"` // 允许流转到的状态ID，不提供时不限制，空数组表示不能流转到其它状态
+}
+
+type TaskDependencyForm struct {
+	TaskId   uint `json:"task_id" binding:"required"`   // 任务ID
+	DependId uint `json:"depend_id" binding:"required"` // 依赖的任务ID
+}
+
+type TaskRecurrenceForm struct {
+	TaskId    uint   `json:"task_id" binding:"required"` // 模板任务ID
+	Rule      string `json:"rule" binding:"required"`    // RRULE 格式，例如 FREQ=WEEKLY;BYDAY=MO,FR
+	StartDate string `json:"start_date"`                 // 开始日期，为空时从今天开始
+	EndDate   string `json:"end_date"`                   // 结束日期，为空时不结束
+}
+
+type TaskFieldForm struct {
+	UintId
+	ProjectId uint     `json:"project" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Type      string   `json:"type" binding:"required"`
+	Options   []string `json:"options"` // 单选、多选的选项
+	Required  bool     `json:"required"`
+	Sort      int      `json:"sort"`
+}
+
+type TaskCommentForm struct {
+	UintId
+	TaskId   uint     `json:"task_id"`                    // 任务ID，编辑时不需要
+	ParentId uint     `json:"parent_id"`                  // 回复的评论ID，编辑时不需要
+	Content  string   `json:"content" binding:"required"` // Markdown 格式
+	Mentions []uint64 `json:"mentions"`                   // 提及的项目成员
 }
 
 type TaskGroupForm struct {
@@ -80,11 +143,23 @@ type DailySituationVo struct {
 }
 
 type TaskLogForm struct {
-	TaskId      uint   `json:"task_id"`
-	OperateType string `json:"operate_type"`
-	Operator    uint64 `json:"operator"`
-	OperateTime int64  `json:"operate_time"`
-	Message     string `json:"message"`
+	TaskId      uint            `json:"task_id"`
+	OperateType string          `json:"operate_type"`
+	Operator    uint64          `json:"operator"`
+	OperateTime int64           `json:"operate_time"`
+	Message     string          `json:"message"`
+	Changes     []TaskLogChange `json:"changes"` // 字段级的变更明细
+}
+
+// TaskLogChange 任务日志中单个字段的变更
+type TaskLogChange struct {
+	Field   string      `json:"field"`             // 字段名
+	Label   string      `json:"label"`             // 字段的显示名称
+	Before  interface{} `json:"before"`            // 修改前的值
+	After   interface{} `json:"after"`             // 修改后的值
+	Added   []uint64    `json:"added,omitempty"`   // 新增的成员ID
+	Removed []uint64    `json:"removed,omitempty"` // 移除的成员ID
+	Text    string      `json:"text,omitempty"`    // 渲染后的文本，查询时生成
 }
 
 type TaskLogQuery struct {
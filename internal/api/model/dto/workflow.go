@@ -10,6 +10,32 @@ type WorkflowListQueryDto struct {
 	Status   string `json:"status"`
 	Promoter uint64 `json:"promoter"`
 	System   bool   `json:"system"`
+	// 关联的项目或任务
+	ProjectId uint `json:"project_id"`
+	TaskId    uint `json:"task_id"`
+	// 待办用户，只查询该用户在当前节点未处理的工作流
+	TodoUser uint64 `json:"-"`
+	// 已办用户，只查询该用户审批过的工作流
+	HandledUser uint64 `json:"-"`
+	// 视为已办的日志操作类型
+	HandledActions []string `json:"-"`
+}
+
+const (
+	WorkflowCcRead   = "read"
+	WorkflowCcUnread = "unread"
+)
+
+type WorkflowCcQueryDto struct {
+	PagingQuery
+	WorkflowId uint   `json:"workflow_id"`
+	Read       string `json:"read"` // read-已读 unread-未读，为空表示全部
+	// 抄送人，只能查询自己的抄送
+	UserId uint64 `json:"-"`
+}
+
+type WorkflowCcReadDto struct {
+	Ids []uint `json:"ids"` // 抄送记录ID，为空时全部标记为已读
 }
 
 type WorkflowTypeDto struct {
@@ -19,6 +45,28 @@ type WorkflowTypeDto struct {
 	OrgId      uint   `json:"org_id,omitempty"`
 	OnlyName   string `json:"only_name,omitempty"`
 	System     bool   `json:"system"`
+	FormSchema string `json:"form_schema"` // 表单结构
+	// 编号模板
+	SerialPrefix      string `json:"serial_prefix"`
+	SerialDateFormat  string `json:"serial_date_format"`
+	SerialPadding     int    `json:"serial_padding"`
+	SerialIndependent bool   `json:"serial_independent"`
+	// 关联任务变更为该状态前需要本类型审批完成
+	TaskGateStatus int `json:"task_gate_status"`
+}
+
+type WorkflowTypePublishDto struct {
+	ID     uint   `json:"id" binding:"required"` // 工作流类型ID
+	Remark string `json:"remark"`                // 发布说明
+}
+
+type WorkflowTypeVersionDto struct {
+	ID      uint `json:"id" binding:"required"`      // 工作流类型ID
+	Version int  `json:"version" binding:"required"` // 版本号
+}
+
+type WorkflowTypeOnlyNameDto struct {
+	OnlyName string `json:"only_name" binding:"required"`
 }
 
 type WorkflowTypeQueryDto struct {
@@ -45,6 +93,26 @@ type WorkflowNodeDto struct {
 	Name        string `json:"name"`
 	Action      string `json:"action"`
 	ActionValue string `json:"action_value"`
+	Branches    string `json:"branches"` // 条件分支
+	// 审批超时设置
+	Timeout       int    `json:"timeout"`        // 超时时间(分钟)
+	TimeoutAction string `json:"timeout_action"` // 超时动作
+	FallbackUsers string `json:"fallback_users"` // 备用审批人
+	// 会签设置
+	ApproveMode    string `json:"approve_mode"`    // 会签模式
+	ApproveCount   int    `json:"approve_count"`   // 通过人数
+	ApprovePercent int    `json:"approve_percent"` // 通过比例
+	CountReject    bool   `json:"count_reject"`    // 驳回是否只计为反对票
+	// 并行设置
+	Fork     string `json:"fork"`      // 并行分支
+	JoinMode string `json:"join_mode"` // 汇聚方式
+	// 审批人权限
+	AllowAddSigner bool `json:"allow_add_signer"` // 是否允许加签
+	AllowTransfer  bool `json:"allow_transfer"`   // 是否允许转交
+	// 抄送设置
+	CcUsers       string `json:"cc_users"`        // 抄送人
+	CcAction      string `json:"cc_action"`       // 按节点动作获取抄送人
+	CcActionValue string `json:"cc_action_value"` // 抄送动作的配置
 }
 
 type WorkflowNodeQueryDto struct {
@@ -66,12 +134,114 @@ type WorkflowNodeQueryBo struct {
 	CreateTime []int64 `json:"create_time,omitempty"`
 }
 
+// WorkflowDefinition 工作流定义文档，包含工作流类型与所有节点，用于导入导出
+type WorkflowDefinition struct {
+	Version string                   `json:"version" yaml:"version"` // 导出时的工作流引擎版本
+	Type    WorkflowDefinitionType   `json:"type" yaml:"type"`
+	Nodes   []WorkflowDefinitionNode `json:"nodes" yaml:"nodes"`
+}
+
+type WorkflowDefinitionType struct {
+	Name              string `json:"name" yaml:"name"`
+	OnlyName          string `json:"only_name" yaml:"only_name"`
+	Illustrate        string `json:"illustrate,omitempty" yaml:"illustrate,omitempty"`
+	OrgId             uint   `json:"org_id,omitempty" yaml:"org_id,omitempty"`
+	System            bool   `json:"system" yaml:"system"`
+	FormSchema        string `json:"form_schema,omitempty" yaml:"form_schema,omitempty"`
+	SerialPrefix      string `json:"serial_prefix,omitempty" yaml:"serial_prefix,omitempty"`
+	SerialDateFormat  string `json:"serial_date_format,omitempty" yaml:"serial_date_format,omitempty"`
+	SerialPadding     int    `json:"serial_padding,omitempty" yaml:"serial_padding,omitempty"`
+	SerialIndependent bool   `json:"serial_independent,omitempty" yaml:"serial_independent,omitempty"`
+	TaskGateStatus    int    `json:"task_gate_status,omitempty" yaml:"task_gate_status,omitempty"`
+}
+
+type WorkflowDefinitionNode struct {
+	Node           int    `json:"node" yaml:"node"`
+	Name           string `json:"name" yaml:"name"`
+	Action         string `json:"action,omitempty" yaml:"action,omitempty"`
+	ActionValue    string `json:"action_value,omitempty" yaml:"action_value,omitempty"`
+	Branches       string `json:"branches,omitempty" yaml:"branches,omitempty"`
+	Timeout        int    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	TimeoutAction  string `json:"timeout_action,omitempty" yaml:"timeout_action,omitempty"`
+	FallbackUsers  string `json:"fallback_users,omitempty" yaml:"fallback_users,omitempty"`
+	ApproveMode    string `json:"approve_mode,omitempty" yaml:"approve_mode,omitempty"`
+	ApproveCount   int    `json:"approve_count,omitempty" yaml:"approve_count,omitempty"`
+	ApprovePercent int    `json:"approve_percent,omitempty" yaml:"approve_percent,omitempty"`
+	CountReject    bool   `json:"count_reject,omitempty" yaml:"count_reject,omitempty"`
+	Fork           string `json:"fork,omitempty" yaml:"fork,omitempty"`
+	JoinMode       string `json:"join_mode,omitempty" yaml:"join_mode,omitempty"`
+	AllowAddSigner bool   `json:"allow_add_signer,omitempty" yaml:"allow_add_signer,omitempty"`
+	AllowTransfer  bool   `json:"allow_transfer,omitempty" yaml:"allow_transfer,omitempty"`
+	CcUsers        string `json:"cc_users,omitempty" yaml:"cc_users,omitempty"`
+	CcAction       string `json:"cc_action,omitempty" yaml:"cc_action,omitempty"`
+	CcActionValue  string `json:"cc_action_value,omitempty" yaml:"cc_action_value,omitempty"`
+}
+
+type WorkflowDefinitionExportDto struct {
+	ID     uint   `json:"id" binding:"required"` // 工作流类型ID
+	Format string `json:"format"`                // json 或 yaml，默认 json
+}
+
+type WorkflowDefinitionImportDto struct {
+	Format  string `json:"format"`                     // json 或 yaml，默认 json
+	Content string `json:"content" binding:"required"` // 定义文档内容
+	Publish bool   `json:"publish"`                    // 导入后是否发布新版本
+}
+
+// WorkflowDefinitionFile 导出的定义文档
+type WorkflowDefinitionFile struct {
+	Format   string `json:"format"`
+	Filename string `json:"filename"`
+	Content  string `json:"content"`
+}
+
 type WorkflowInitiateDto struct {
-	TypeId uint   `json:"type_id,omitempty"` // 工作流类型ID
-	Title  string `json:"title"`
+	TypeId    uint                   `json:"type_id,omitempty"` // 工作流类型ID
+	Title     string                 `json:"title"`
+	ProjectId uint                   `json:"project_id"` // 关联的项目
+	TaskId    uint                   `json:"task_id"`    // 关联的任务，项目为空时使用任务所在的项目
+	Data      map[string]interface{} `json:"data"`       // 表单数据
+}
+
+type WorkflowSimulateDto struct {
+	TypeId    uint                   `json:"type_id" binding:"required"` // 工作流类型ID
+	Initiator uint64                 `json:"initiator"`                  // 假设的发起人，为空时使用当前用户
+	ProjectId uint                   `json:"project_id"`                 // 关联的项目
+	TaskId    uint                   `json:"task_id"`                    // 关联的任务
+	Data      map[string]interface{} `json:"data"`                       // 表单数据
+	Version   int                    `json:"version"`                    // 模拟的定义版本，为0时使用正在编辑的节点
 }
 
 type WorkflowExamineApproveDto struct {
-	WorkflowId uint   `json:"workflow_id,omitempty"` // 工作流ID
-	Action     string `json:"action"`                // 动作 作废 进行 驳回
+	WorkflowId uint                   `json:"workflow_id,omitempty"` // 工作流ID
+	Action     string                 `json:"action"`                // 动作 作废 进行 驳回 撤回 加签 转交
+	JumpNode   int                    `json:"jump_node,omitempty"`   // 驳回到指定节点
+	Node       int                    `json:"node,omitempty"`        // 并行审批时要处理的分支节点，为空时自动选择
+	SignType   string                 `json:"sign_type,omitempty"`   // 加签方式 before-前加签 after-后加签
+	Signers    []uint64               `json:"signers,omitempty"`     // 加签人ID
+	TransferTo uint64                 `json:"transfer_to,omitempty"` // 转交给该用户
+	Comment    string                 `json:"comment"`               // 审批意见
+	Data       map[string]interface{} `json:"data"`                  // 表单数据
+}
+
+type WorkflowWithdrawDto struct {
+	WorkflowId uint   `json:"workflow_id" binding:"required"` // 工作流ID
+	Comment    string `json:"comment"`                        // 撤回原因
+}
+
+type WorkflowDelegationDto struct {
+	UintId
+	DelegateId uint64   `json:"delegate_id" binding:"required"` // 代理人ID
+	Date       []string `json:"date" binding:"required"`        // 有效期，开始日期与结束日期
+	TypeIds    []uint   `json:"type_ids"`                       // 适用的工作流类型，为空表示全部
+	Remark     string   `json:"remark"`
+}
+
+type WorkflowIdDto struct {
+	WorkflowId uint `json:"workflow_id" binding:"required"` // 工作流ID
+}
+
+type WorkflowDataUpdateDto struct {
+	WorkflowId uint                   `json:"workflow_id" binding:"required"` // 工作流ID
+	Data       map[string]interface{} `json:"data" binding:"required"`        // 表单数据
 }
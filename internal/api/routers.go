@@ -110,6 +110,50 @@ func Routers(r *gin.Engine) {
 			gg.POST("list", taskLogApi.List)
 			gg.POST("operators", taskLogApi.Operators)
 		}
+
+		{
+			// 任务依赖接口
+			gg := g.Group("dependency")
+			gg.POST("add", taskApi.DependencyAdd)
+			gg.POST("delete", taskApi.DependencyDelete)
+			gg.POST("list", taskApi.DependencyList)
+		}
+
+		{
+			// 重复任务接口，ID为模板任务ID
+			gg := g.Group("recurrence")
+			gg.POST("set", taskApi.RecurrenceSet)
+			gg.POST("delete", taskApi.RecurrenceDelete)
+			gg.POST("detail", taskApi.RecurrenceDetail)
+		}
+
+		{
+			// 任务自定义字段接口，列表的ID为项目ID
+			gg := g.Group("field")
+			gg.POST("add", taskApi.FieldAdd)
+			gg.POST("update", taskApi.FieldUpdate)
+			gg.POST("delete", taskApi.FieldDelete)
+			gg.POST("list", taskApi.FieldList)
+			gg.POST("types", taskApi.FieldTypes)
+		}
+
+		{
+			// 项目自定义任务状态接口，列表的ID为项目ID
+			gg := g.Group("status-config")
+			gg.POST("save", taskApi.StatusConfigSave)
+			gg.POST("delete", taskApi.StatusConfigDelete)
+			gg.POST("list", taskApi.StatusConfigList)
+		}
+
+		{
+			// 任务评论接口，列表的ID为任务ID，历史的ID为评论ID
+			gg := g.Group("comment")
+			gg.POST("add", taskApi.CommentAdd)
+			gg.POST("update", taskApi.CommentUpdate)
+			gg.POST("delete", taskApi.CommentDelete)
+			gg.POST("list", taskApi.CommentList)
+			gg.POST("history", taskApi.CommentHistory)
+		}
 	}
 
 	{
@@ -125,11 +169,20 @@ func Routers(r *gin.Engine) {
 		g := r.Group("workflow", middleware.CheckLogin())
 		g.POST("initiate", workflowApi.Initiate)
 		g.POST("examine-approve", workflowApi.ExamineApprove)
+		g.POST("withdraw", workflowApi.Withdraw)
+		g.POST("simulate", workflowApi.Simulate)
 		g.POST("all", workflowApi.All)
 		g.POST("todo", workflowApi.ToDo)
 		g.POST("handled", workflowApi.Handled)
+		g.POST("cc", workflowApi.Cc)
+		g.POST("cc/read", workflowApi.CcRead)
+		g.GET("cc/unread", workflowApi.CcUnread)
 		g.POST("list", workflowApi.List)
 		g.GET("status/list", workflowApi.StatusList)
+		g.POST("data", workflowApi.Data)
+		g.POST("data/versions", workflowApi.DataVersions)
+		g.POST("data/update", workflowApi.DataUpdate)
+		g.POST("log", workflowApi.Log)
 
 		{
 			twoG := g.Group("type")
@@ -138,7 +191,13 @@ func Routers(r *gin.Engine) {
 			twoG.POST("list", workflowApi.TypeList)
 			twoG.POST("delete", workflowApi.TypeDelete)
 			twoG.POST("detail", workflowApi.TypeDetail)
+			twoG.POST("schema", workflowApi.TypeSchema)
 			twoG.GET("options", workflowApi.TypeOptions)
+			twoG.POST("export", workflowApi.TypeExport)
+			twoG.POST("import", workflowApi.TypeImport)
+			twoG.POST("publish", workflowApi.TypePublish)
+			twoG.POST("versions", workflowApi.TypeVersions)
+			twoG.POST("version", workflowApi.TypeVersionDetail)
 		}
 
 		{
@@ -148,6 +207,17 @@ func Routers(r *gin.Engine) {
 			twoG.POST("list", workflowApi.NodeList)
 			twoG.POST("delete", workflowApi.NodeDelete)
 			twoG.POST("actions", workflowApi.Actions)
+			twoG.POST("timeout-actions", workflowApi.TimeoutActions)
+			twoG.POST("approve-modes", workflowApi.ApproveModes)
+			twoG.POST("join-modes", workflowApi.JoinModes)
+		}
+
+		{
+			twoG := g.Group("delegation")
+			twoG.POST("list", workflowApi.DelegationList)
+			twoG.POST("add", workflowApi.DelegationAdd)
+			twoG.POST("update", workflowApi.DelegationUpdate)
+			twoG.POST("delete", workflowApi.DelegationDelete)
 		}
 	}
 }
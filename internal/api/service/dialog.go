@@ -134,11 +134,6 @@ func (receiver DialogService) Create(name, t string, members []uint64) (*repo.Di
 	if !receiver.CheckType(t) {
 		return nil, exception.NewException(response.DialogTypeError)
 	}
-	// 获取当前登录人
-	currUser, err := auth.CurrUser(receiver.ctx)
-	if err != nil {
-		return nil, err
-	}
 	// 用户数组去重
 	members = pkg.SliceUnique(members)
 	// 加入成员
@@ -147,6 +142,11 @@ func (receiver DialogService) Create(name, t string, members []uint64) (*repo.Di
 	}
 	// 如果是 C2C 类型
 	if t == constant.DialogTypeC2C {
+		// 获取当前登录人，其它类型的对话不需要，可以在后台任务中创建
+		currUser, err := auth.CurrUser(receiver.ctx)
+		if err != nil {
+			return nil, err
+		}
 		// 只允许一个聊天对象
 		if len(members) > 1 {
 			return nil, exception.NewException(response.DialogC2COvercrowding)
@@ -160,7 +160,7 @@ func (receiver DialogService) Create(name, t string, members []uint64) (*repo.Di
 	// 创建对话实例
 	dialog := repo.Dialog{Name: name, Type: t}
 	// 开始创建
-	err = receiver.Db.Transaction(func(tx *gorm.DB) error {
+	err := receiver.Db.Transaction(func(tx *gorm.DB) error {
 		// 此处要创建新的Repo
 		dialogRepo := data.NewDialogRepo(tx, receiver.ctx)
 		dialogUserRepo := data.NewDialogUserRepo(tx, receiver.ctx)
@@ -354,6 +354,51 @@ func (receiver *ProjectMemberService) InProject(projectId uint, userId uint64, r
 	return receiver.repo.InProject(projectId, userId, roleWhereIn)
 }
 
+// IsMember 用户是否为项目的创建人、负责人或成员，只收藏项目的用户不算
+func (receiver *ProjectMemberService) IsMember(projectId uint, userId uint64) bool {
+	for _, role := range []int{constant.ProjectCreate, constant.ProjectLeader, constant.ProjectMember} {
+		if receiver.InProject(projectId, userId, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckMember 当前用户是否为项目成员，并且项目未归档，返回当前用户
+func (receiver *ProjectMemberService) CheckMember(projectId uint) (*repo.User, error) {
+	currUser, err := auth.CurrUser(receiver.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !receiver.IsMember(projectId, currUser.ID) {
+		return nil, exception.NewException(response.MemberNotInProject, "您不属于项目成员")
+	}
+	// 项目是否归档
+	if data.NewProjectRepo(receiver.Db, receiver.ctx).Archived(projectId) {
+		return nil, exception.NewException(response.ProjectArchived)
+	}
+	return currUser, nil
+}
+
+// CheckLeader 当前用户是否为项目负责人，并且项目未归档，返回当前用户
+// 用于修改项目的配置
+func (receiver *ProjectMemberService) CheckLeader(projectId uint) (*repo.User, error) {
+	currUser, err := auth.CurrUser(receiver.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !receiver.IsLeader(projectId, currUser.ID) {
+		return nil, exception.NewException(response.MemberNotProjectLeader, "你不是项目负责人")
+	}
+	// 项目是否归档
+	if data.NewProjectRepo(receiver.Db, receiver.ctx).Archived(projectId) {
+		return nil, exception.NewException(response.ProjectArchived)
+	}
+	return currUser, nil
+}
+
 // GetMembersByRole 根据角色获取成员
 func (receiver *ProjectMemberService) GetMembersByRole(projectId uint, role int) ([]repo.ProjectMember, error) {
 	// 获取所有角色
@@ -488,5 +533,6 @@ func (receiver *ProjectMemberService) IsLeader(projectId uint, userId uint64) bo
 		return false
 	}
 
-	return leader.UserId == userId
+	// 项目可能没有负责人
+	return leader != nil && leader.UserId == userId
 }
@@ -6,6 +6,7 @@ import (
 	"VitaTaskGo/internal/pkg"
 	"VitaTaskGo/internal/pkg/auth"
 	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/pkg/workflow"
 	"VitaTaskGo/internal/repo"
 	"VitaTaskGo/pkg/db"
 	"VitaTaskGo/pkg/exception"
@@ -63,6 +64,17 @@ func (receiver TaskService) Lists(query dto.TaskListQuery) (*dto.PagedResult[rep
 		}
 	}
 
+	// 按层级查询时附带子任务
+	if query.TopLevel || query.ParentId > 0 {
+		roots := make([]*repo.Task, len(tasks))
+		for i := range tasks {
+			roots[i] = &tasks[i]
+		}
+		if err := receiver.fillChildren(roots); err != nil {
+			return pkg.PagedResult[repo.Task](nil, 0, int64(query.Page)), err
+		}
+	}
+
 	return pkg.PagedResult(tasks, total, int64(query.Page)), nil
 }
 
@@ -109,6 +121,26 @@ func (receiver TaskService) QueryHandle(query dto.TaskListQuery) *dto.TaskListQu
 			bo.CollaboratorTaskIds = taskIds
 		}
 	}
+	// 自定义字段，多个条件取交集
+	for i, filter := range query.Fields {
+		field, err := data.NewTaskFieldRepo(receiver.Db, receiver.ctx).Get(filter.FieldId)
+		if err != nil {
+			return nil
+		}
+		taskIds, err := data.NewTaskFieldValueRepo(receiver.Db, receiver.ctx).GetTaskIds(field, filter)
+		if err != nil {
+			_ = exception.ErrorHandle(err, response.DbQueryError)
+			return nil
+		}
+		if i > 0 {
+			taskIds = slice.Intersection(bo.FieldTaskIds, taskIds)
+		}
+		if len(taskIds) <= 0 {
+			// 没有符合条件的任务
+			return nil
+		}
+		bo.FieldTaskIds = taskIds
+	}
 	return bo
 }
 
@@ -119,7 +151,12 @@ func (receiver TaskService) Create(post dto.TaskCreateForm) (*repo.Task, error)
 	if err != nil {
 		return nil, err
 	}
+	return receiver.CreateAs(currUser, post)
+}
 
+// CreateAs 以指定用户的身份创建任务，规则与 Create 相同
+// 不依赖请求上下文，可以在后台任务中使用
+func (receiver TaskService) CreateAs(currUser *repo.User, post dto.TaskCreateForm) (*repo.Task, error) {
 	// 项目是否存在
 	projectRepo := data.NewProjectRepo(receiver.Db, receiver.ctx)
 	if !projectRepo.Exist(post.ProjectId) {
@@ -136,11 +173,27 @@ func (receiver TaskService) Create(post dto.TaskCreateForm) (*repo.Task, error)
 		return nil, exception.NewException(response.ProjectArchived)
 	}
 
+	// 父任务
+	if err := receiver.checkParent(nil, post.ParentId, post.ProjectId); err != nil {
+		return nil, err
+	}
+
+	// 自定义字段
+	fieldValues, err := NewTaskFieldService(receiver.Db, receiver.ctx).checkValues(post.ProjectId, post.Fields, true)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建任务模型
 	task, err := receiver.NewTask(post)
 	if err != nil {
 		return nil, err
 	}
+	// 项目有自定义状态时使用初始状态
+	if status := NewTaskStatusService(receiver.Db, receiver.ctx).defaultStatus(post.ProjectId); status != nil {
+		task.StatusId = status.ID
+		task.Status = uint8(status.Category)
+	}
 
 	transactionErr := receiver.Db.Transaction(func(tx *gorm.DB) error {
 		// 重新实例化Repo
@@ -205,9 +258,15 @@ func (receiver TaskService) Create(post dto.TaskCreateForm) (*repo.Task, error)
 		_, err = taskLogService.Add(dto.TaskLogForm{
 			TaskId:      task.ID,
 			OperateType: constant.TaskOperatorCreate,
+			Operator:    currUser.ID,
 			Message:     "创建了任务",
 		})
-		return err
+		if err != nil {
+			return err
+		}
+
+		// 保存自定义字段
+		return NewTaskFieldService(tx, receiver.ctx).saveValues(task.ID, fieldValues)
 	})
 
 	if err := exception.ErrorHandle(transactionErr, response.TaskCreateFail, "创建任务失败: "); err != nil {
@@ -222,6 +281,7 @@ func (receiver TaskService) NewTask(data dto.TaskCreateForm) (*repo.Task, error)
 	task := &repo.Task{
 		ProjectId: data.ProjectId,
 		GroupId:   data.GroupId,
+		ParentId:  data.ParentId,
 		Title:     data.Title,
 		Describe:  data.Describe,
 		Status:    0, // 新任务是未完成的
@@ -269,6 +329,65 @@ func (receiver TaskService) Detail(taskId uint) (*repo.Task, error) {
 			task.Collaborator = append(task.Collaborator, member)
 		}
 	}
+
+	// 关联的审批，非项目成员只能看到自己可以查看的审批
+	workflows, err := data.NewWorkflowRepo(receiver.Db, receiver.ctx).GetTaskWorkflows(task.ID)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	currUser, err := auth.CurrUser(receiver.ctx)
+	if err != nil {
+		return nil, err
+	}
+	isMember := NewProjectMemberService(receiver.Db, receiver.ctx).IsMember(task.ProjectId, currUser.ID)
+	workflowService := NewWorkflowService(receiver.Db, receiver.ctx)
+	task.Workflows = make([]repo.Workflow, 0, len(workflows))
+	for _, item := range workflows {
+		if !isMember && workflowService.checkViewer(item.ID) != nil {
+			continue
+		}
+		item.StatusText = workflow.StatusName(item.Status)
+		task.Workflows = append(task.Workflows, item)
+	}
+
+	// 前置任务与后续任务
+	dependencies, err := NewTaskDependencyService(receiver.Db, receiver.ctx).List(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	task.Blockers = dependencies.Blockers
+	task.Dependents = dependencies.Dependents
+
+	// 子任务
+	if err := receiver.fillChildren([]*repo.Task{task}); err != nil {
+		return nil, err
+	}
+
+	// 自定义字段
+	task.Fields, err = NewTaskFieldService(receiver.Db, receiver.ctx).TaskValues(task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复规则
+	task.Recurrence, err = NewTaskRecurrenceService(receiver.Db, receiver.ctx).Detail(task.ID)
+	if err != nil {
+		// 没有设置重复规则
+		if !exception.IsCode(err, response.TaskRecurrenceNotExist) {
+			return nil, err
+		}
+		task.Recurrence = nil
+	}
+
+	// 评论
+	task.Comments, err = NewTaskCommentService(receiver.Db, receiver.ctx).List(task.ID)
+	if err != nil {
+		// 非项目成员不能查看评论
+		if !exception.IsCode(err, response.MemberNotInProject) {
+			return nil, err
+		}
+		task.Comments = nil
+	}
 	return task, nil
 }
 
@@ -279,7 +398,26 @@ func (receiver TaskService) Roles() map[int]string {
 }
 
 // Status 获取所有状态
-func (receiver TaskService) Status() []dto.TaskStatusVo {
+// 项目有自定义状态时返回项目的状态，否则返回默认的状态
+func (receiver TaskService) Status(projectId uint) []dto.TaskStatusVo {
+	if projectId > 0 {
+		statuses, err := NewTaskStatusService(receiver.Db, receiver.ctx).List(projectId)
+		if err == nil && len(statuses) > 0 {
+			taskStatusVO := make([]dto.TaskStatusVo, len(statuses))
+			for i, item := range statuses {
+				taskStatusVO[i] = dto.TaskStatusVo{
+					Label:       item.Name,
+					Value:       item.Category,
+					Status:      receiver.statusDrop(item.Category),
+					Id:          item.ID,
+					Color:       item.Color,
+					Transitions: item.Transitions,
+				}
+			}
+			return taskStatusVO
+		}
+	}
+
 	statusMap := constant.GetTaskStatus()
 	// 获取Keys
 	statusMapKeys := maputil.Keys(statusMap)
@@ -294,23 +432,11 @@ func (receiver TaskService) Status() []dto.TaskStatusVo {
 		if !ok {
 			continue
 		}
-		statusDrop := "processing" // 状态点
-		switch t {
-		case constant.TaskStatusProcessing:
-			statusDrop = "processing"
-			break
-		case constant.TaskStatusCompleted:
-			statusDrop = "success"
-			break
-		case constant.TaskStatusArchived:
-			statusDrop = "default"
-			break
-		}
 		// 转成VO
 		taskStatusVO[i] = dto.TaskStatusVo{
 			Label:  status,
 			Value:  t,
-			Status: statusDrop,
+			Status: receiver.statusDrop(t),
 		}
 		i++
 	}
@@ -318,9 +444,42 @@ func (receiver TaskService) Status() []dto.TaskStatusVo {
 	return taskStatusVO
 }
 
+// statusDrop 状态分类对应的状态点
+func (receiver TaskService) statusDrop(category int) string {
+	switch category {
+	case constant.TaskCategoryDone:
+		return "success"
+	case constant.TaskCategoryArchived:
+		return "default"
+	}
+	return "processing"
+}
+
+func init() {
+	// 工作流修改任务状态时使用与接口相同的规则
+	workflow.RegisterTaskStatusHandler(func(tx *gorm.DB, actor *repo.User, taskId uint, category int, reason string) error {
+		return NewTaskService(tx, nil).ChangeStatusAs(actor, dto.TaskChangeStatus{
+			SingleUintRequired: dto.SingleUintRequired{ID: taskId},
+			Status:             category,
+		}, reason)
+	})
+}
+
 // ChangeStatus 更改任务状态
-func (receiver TaskService) ChangeStatus(taskId uint, status int) error {
-	task, err := receiver.repo.Detail(taskId)
+// 项目有自定义状态时按状态配置的流转规则校验，完成、归档等处理按状态分类进行
+// 前置任务仍在进行中时不能完成任务，force 为 true 时仍然完成并在日志中记录
+func (receiver TaskService) ChangeStatus(post dto.TaskChangeStatus) error {
+	currUser, err := auth.CurrUser(receiver.ctx)
+	if err != nil {
+		return err
+	}
+	return receiver.ChangeStatusAs(currUser, post, "")
+}
+
+// ChangeStatusAs 以指定用户的身份更改任务状态，规则与 ChangeStatus 相同
+// 用于没有请求上下文的流程，reason 不为空时作为日志的前缀
+func (receiver TaskService) ChangeStatusAs(actor *repo.User, post dto.TaskChangeStatus, reason string) error {
+	task, err := receiver.repo.Detail(post.ID)
 	if err != nil {
 		return db.FirstQueryErrorHandle(err, response.TaskNotExist)
 	}
@@ -330,25 +489,57 @@ func (receiver TaskService) ChangeStatus(taskId uint, status int) error {
 		return exception.NewException(response.ProjectArchived)
 	}
 
-	// 获取状态Map
-	statusMap := constant.GetTaskStatus()
-	if _, ok := statusMap[status]; !ok {
-		return exception.NewException(response.TaskStatusNotExist)
+	// 目标状态
+	taskStatusService := NewTaskStatusService(receiver.Db, receiver.ctx)
+	target, err := taskStatusService.resolve(task.ProjectId, post.StatusId, post.Status)
+	if err != nil {
+		return err
+	}
+	category, statusName := post.Status, constant.GetTaskStatus()[post.Status]
+	if target != nil {
+		category, statusName = target.Category, target.Name
+		// 是否允许流转
+		if err := taskStatusService.checkTransition(task, target); err != nil {
+			return err
+		}
+	} else if category == constant.TaskCategoryArchived && int(task.Status) != constant.TaskCategoryDone {
+		// 没有自定义状态时，只有已完成的任务可以归档
+		return exception.NewException(response.TaskStatusProcessing, "未完成的任务不能归档")
 	}
+
+	// 是否需要审批
+	if err := receiver.checkApproval(task.ID, category); err != nil {
+		return err
+	}
+
+	// 前置任务是否完成
+	logMessage := reason + fmt.Sprintf("修改了任务状态为[%s]", statusName)
+	if category == constant.TaskCategoryDone {
+		blockers, err := NewTaskDependencyService(receiver.Db, receiver.ctx).ProcessingBlockers(task.ID)
+		if err != nil {
+			return err
+		}
+		if len(blockers) > 0 {
+			if !post.Force {
+				return exception.NewException(response.TaskBlocked, fmt.Sprintf("前置任务[%s]仍在进行中", blockerTitles(blockers)))
+			}
+			logMessage += fmt.Sprintf("，前置任务[%s]仍在进行中", blockerTitles(blockers))
+		}
+	}
+
 	// 创建待修改数据的Map
 	updates := make(map[string]interface{})
-	// 保存要修改的状态值
-	updates["status"] = status
-	// 如果要对任务进行归档
-	if status == constant.TaskStatusArchived {
-		if task.Status != 1 {
-			return exception.NewException(response.TaskStatusProcessing, "未完成的任务不能归档")
-		}
+	// 保存要修改的状态分类与状态，分类下没有自定义状态时清空状态，避免状态与分类不一致
+	updates["status"] = category
+	updates["status_id"] = 0
+	if target != nil {
+		updates["status_id"] = target.ID
+	}
+	if category == constant.TaskCategoryArchived {
 		// 记录归档时间
 		updates["archived_date"] = time.Now().UnixMilli()
-	} else if status == constant.TaskStatusCompleted {
-		// 如果是标记为已完成
-		// 记录完成时间
+	} else if category == constant.TaskCategoryDone && int(task.Status) != constant.TaskCategoryDone {
+		// 从其它分类变为已完成时记录完成时间
 		updates["complete_date"] = time.Now().UnixMilli()
 	}
 	// 更改状态
@@ -358,14 +549,42 @@ func (receiver TaskService) ChangeStatus(taskId uint, status int) error {
 	}
 
 	// 记录日志
+	beforeName := constant.GetTaskStatus()[int(task.Status)]
+	if task.StatusInfo != nil {
+		beforeName = task.StatusInfo.Name
+	}
 	_, err = NewTaskLogService(receiver.Db, receiver.ctx).Add(dto.TaskLogForm{
 		TaskId:      task.ID,
 		OperateType: constant.TaskOperatorStatus,
-		Message:     fmt.Sprintf("修改了任务状态为[%s]", statusMap[status]),
+		Operator:    actor.ID,
+		Message:     logMessage,
+		Changes:     []dto.TaskLogChange{{Field: logFieldStatus, Label: "状态", Before: beforeName, After: statusName}},
 	})
 	return err
 }
 
+// checkApproval 任务变更为该状态分类前需要的审批是否都已完成
+func (receiver TaskService) checkApproval(taskId uint, status int) error {
+	if status == constant.TaskCategoryOpen {
+		return nil
+	}
+
+	gates, err := data.NewWorkflowTypeRepo(receiver.Db, receiver.ctx).GetTaskGates(status)
+	if err != nil {
+		return exception.ErrorHandle(err, response.DbQueryError)
+	}
+	workflowRepo := data.NewWorkflowRepo(receiver.Db, receiver.ctx)
+	for _, gate := range gates {
+		if !workflowRepo.ExistTaskWorkflow(taskId, gate.ID, workflow.StatusCompleted) {
+			return exception.NewException(
+				response.TaskApprovalRequired,
+				fmt.Sprintf("需要[%s]审批通过后才能修改为[%s]", gate.Name, constant.GetTaskStatus()[status]),
+			)
+		}
+	}
+	return nil
+}
+
 // Update 更新任务
 func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.Task, error) {
 	// 项目是否存在
@@ -392,30 +611,47 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 		return nil, exception.ErrorHandle(taskErr, response.TaskNotExist)
 	}
 
+	// 父任务
+	if err := receiver.checkParent(task, post.ParentId, post.ProjectId); err != nil {
+		return nil, err
+	}
+
+	// 自定义字段
+	fieldValues, err := NewTaskFieldService(receiver.Db, receiver.ctx).checkValues(post.ProjectId, post.Fields, task.ProjectId != post.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
 	// 更新各个字段
 	taskSave := map[string]interface{}{
 		"project_id": post.ProjectId,
 		"group_id":   post.GroupId,
+		"parent_id":  post.ParentId,
 		"title":      post.Title,
 		"describe":   post.Describe,
 		"level":      post.Level,
 	}
-	// 计划时间
+	// 移动到其它项目时，使用新项目中同一分类的状态
+	if task.ProjectId != post.ProjectId {
+		taskSave["status_id"] = NewTaskStatusService(receiver.Db, receiver.ctx).statusIdByCategory(post.ProjectId, int(task.Status))
+	}
+	// 计划时间，未提供时清空
+	var startDate, endDate int64
 	if len(post.PlanTime) >= 2 {
 		planTime, err := time_tool.ParseTimeRangeToUnix(post.PlanTime, time.DateOnly, "milli")
 		if err != nil {
 			return nil, err
 		}
-		taskSave["start_date"] = planTime[0] // 开始时间
+		startDate = planTime[0] // 开始时间
 		// 把结束时间调整为当天最后1秒
-		t := time.Unix(planTime[1]/1e3, 0)                                                                      // 解析时间戳
-		taskSave["end_date"] = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location()).UnixMilli() // 结束时间
-	} else {
-		// 清空计划时间
-		taskSave["start_date"] = 0 // 开始时间
-		taskSave["end_date"] = 0   // 结束时间
+		t := time.Unix(planTime[1]/1e3, 0)                                                         // 解析时间戳
+		endDate = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, t.Location()).UnixMilli() // 结束时间
 	}
-	err := receiver.Db.Transaction(func(tx *gorm.DB) error {
+	taskSave["start_date"] = startDate
+	taskSave["end_date"] = endDate
+	// 变更明细
+	changes := taskChanges(task, post, startDate, endDate)
+	err = receiver.Db.Transaction(func(tx *gorm.DB) error {
 		// 实例化Repo
 		taskRepo := data.NewTaskRepo(tx, receiver.ctx)
 		taskMemberRepo := data.NewTaskMemberRepo(tx, receiver.ctx)
@@ -429,19 +665,45 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 			return err
 		}
 
-		// 记录日志
-		_, err := taskLogService.Add(dto.TaskLogForm{
-			TaskId:      task.ID,
-			OperateType: constant.TaskOperatorUpdate,
-			Message:     "修改了任务信息",
-		})
+		// 记录日志，只记录有变化的字段
+		if len(changes) > 0 {
+			_, err := taskLogService.Add(dto.TaskLogForm{
+				TaskId:      task.ID,
+				OperateType: constant.TaskOperatorUpdate,
+				Message:     fmt.Sprintf("修改了%s", changeLabels(changes)),
+				Changes:     changes,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// 移动到其它项目时，所有子任务一起移动
+		if task.ProjectId != post.ProjectId {
+			if err := receiver.moveChildren(tx, task, post.ProjectId, post.GroupId); err != nil {
+				return err
+			}
+			// 自定义字段属于项目，原项目的字段值不再有效
+			if err := data.NewTaskFieldValueRepo(tx, receiver.ctx).DeleteByTask(task.ID); err != nil {
+				return err
+			}
+		}
+
+		// 保存自定义字段
+		if err := NewTaskFieldService(tx, receiver.ctx).saveValues(task.ID, fieldValues); err != nil {
+			return err
+		}
 
 		/* 保存负责人 Start */
+		var oldLeader []uint64
+		if task.Leader != nil {
+			oldLeader = []uint64{task.Leader.UserId}
+		}
 		if post.Leader > 0 {
 			// 负责人是否变更
-			if task.Leader.UserId != post.Leader {
+			if change := memberChange(logFieldLeader, "负责人", oldLeader, []uint64{post.Leader}); change != nil {
 				// 删除旧负责人
-				err := taskMemberService.RemoveRole(task.ID, []uint64{task.Leader.UserId}, constant.TaskLeader)
+				err := taskMemberService.RemoveRole(task.ID, oldLeader, constant.TaskLeader)
 				if err != nil {
 					return err
 				}
@@ -457,12 +719,13 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 					TaskId:      task.ID,
 					OperateType: constant.TaskOperatorChangeLeader,
 					Message:     "变更了负责人",
+					Changes:     []dto.TaskLogChange{*change},
 				})
 				if err != nil {
 					return err
 				}
 			}
-		} else {
+		} else if len(oldLeader) > 0 {
 			// 没有提供负责人参数，移除当前负责人
 			err := taskMemberService.RemoveRole(task.ID, nil, constant.TaskLeader)
 			if err != nil {
@@ -474,6 +737,7 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 				TaskId:      task.ID,
 				OperateType: constant.TaskOperatorRemoveLeader,
 				Message:     "移除负责人",
+				Changes:     []dto.TaskLogChange{*memberChange(logFieldLeader, "负责人", oldLeader, nil)},
 			})
 			if err != nil {
 				return err
@@ -482,27 +746,33 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 		/* 保存负责人 End */
 
 		/* 保存协作人 Start */
-		// 先移除所有协作人
-		err = taskMemberService.RemoveRole(task.ID, nil, constant.TaskMember)
-		if err != nil {
-			return err
-		}
+		oldCollaborator := slice.Map(task.Collaborator, func(_ int, item *repo.TaskMember) uint64 {
+			return item.UserId
+		})
 		// 如果没有提供协作人参数，就认定为移除协作人
-		// 重新绑定
-		if len(post.Collaborator) > 0 {
-			err := taskMemberService.Bind(task.ID, post.Collaborator, constant.TaskMember)
+		if change := memberChange(logFieldCollaborator, "协作人", oldCollaborator, slice.Unique(post.Collaborator)); change != nil {
+			// 先移除所有协作人
+			err = taskMemberService.RemoveRole(task.ID, nil, constant.TaskMember)
+			if err != nil {
+				return err
+			}
+			// 重新绑定
+			if len(post.Collaborator) > 0 {
+				err := taskMemberService.Bind(task.ID, post.Collaborator, constant.TaskMember)
+				if err != nil {
+					return err
+				}
+			}
+			// 记录日志
+			_, err = taskLogService.Add(dto.TaskLogForm{
+				TaskId:      task.ID,
+				OperateType: constant.TaskOperatorChangeCollaborator,
+				Message:     "变更协作人",
+				Changes:     []dto.TaskLogChange{*change},
+			})
 			if err != nil {
 				return err
 			}
-		}
-		// 记录日志
-		_, err = taskLogService.Add(dto.TaskLogForm{
-			TaskId:      task.ID,
-			OperateType: constant.TaskOperatorChangeCollaborator,
-			Message:     "变更协作人",
-		})
-		if err != nil {
-			return err
 		}
 		/* 保存协作人 End */
 
@@ -563,7 +833,7 @@ func (receiver TaskService) Update(taskId uint, post dto.TaskCreateForm) (*repo.
 }
 
 // Delete 删除任务
-// 2023年3月11日 暂时不删除成员以及任务组
+// 所有子任务一起删除，同时删除任务的成员、对话、依赖、重复规则与自定义字段值，不删除任务组
 func (receiver TaskService) Delete(taskId uint) error {
 	task, err := receiver.repo.Detail(taskId)
 	if err != nil {
@@ -573,27 +843,83 @@ func (receiver TaskService) Delete(taskId uint) error {
 	if data.NewProjectRepo(receiver.Db, receiver.ctx).Archived(task.ProjectId) {
 		return exception.NewException(response.ProjectArchived)
 	}
-	// 执行删除
-	err = receiver.repo.Delete(task.ID)
-	if err != nil {
-		return err
-	}
 
-	// 记录日志
-	_, err = NewTaskLogService(receiver.Db, receiver.ctx).Add(dto.TaskLogForm{
-		TaskId:      task.ID,
-		OperateType: constant.TaskOperatorDelete,
-		Message:     "删除了任务",
+	err = receiver.Db.Transaction(func(tx *gorm.DB) error {
+		taskRepo := data.NewTaskRepo(tx, receiver.ctx)
+		taskMemberRepo := data.NewTaskMemberRepo(tx, receiver.ctx)
+		taskDependencyRepo := data.NewTaskDependencyRepo(tx, receiver.ctx)
+		taskRecurrenceRepo := data.NewTaskRecurrenceRepo(tx, receiver.ctx)
+		taskFieldValueRepo := data.NewTaskFieldValueRepo(tx, receiver.ctx)
+		taskCommentRepo := data.NewTaskCommentRepo(tx, receiver.ctx)
+		taskCommentHistoryRepo := data.NewTaskCommentHistoryRepo(tx, receiver.ctx)
+		taskLogService := NewTaskLogService(tx, receiver.ctx)
+		dialogService := NewDialogService(tx, receiver.ctx)
+
+		children, err := receiver.descendants(tx, []uint{task.ID})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range append([]repo.Task{*task}, children...) {
+			// 执行删除
+			if err := taskRepo.Delete(item.ID); err != nil {
+				return err
+			}
+			// 删除成员
+			if err := taskMemberRepo.DeleteTaskAllMember(item.ID); err != nil {
+				return err
+			}
+			// 删除对话
+			if item.DialogId > 0 {
+				if err := dialogService.Delete(item.DialogId); err != nil {
+					return err
+				}
+			}
+			// 删除任务依赖
+			if err := taskDependencyRepo.DeleteByTask(item.ID); err != nil {
+				return err
+			}
+			// 删除重复规则
+			if err := taskRecurrenceRepo.DeleteByTask(item.ID); err != nil {
+				return err
+			}
+			// 删除自定义字段值
+			if err := taskFieldValueRepo.DeleteByTask(item.ID); err != nil {
+				return err
+			}
+			// 删除评论及其历史
+			if err := taskCommentHistoryRepo.DeleteByTask(item.ID); err != nil {
+				return err
+			}
+			if err := taskCommentRepo.DeleteByTask(item.ID); err != nil {
+				return err
+			}
+
+			// 记录日志
+			message := "删除了任务"
+			if item.ID != task.ID {
+				message = fmt.Sprintf("随父任务[%s]删除", task.Title)
+			}
+			_, err := taskLogService.Add(dto.TaskLogForm{
+				TaskId:      item.ID,
+				OperateType: constant.TaskOperatorDelete,
+				Message:     message,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	})
-	return err
+	return exception.ErrorHandle(err, response.TaskDeleteFail, "删除任务失败: ")
 }
 
-// Statistics 任务数量统计
+// Statistics 任务数量统计，按状态分类统计
 // 已完成数量、未完成数量、按时完成数量、超时完成数量
 func (receiver TaskService) Statistics(projectId uint) dto.TaskStatistics {
 	taskStatistics := dto.TaskStatistics{
-		Completed:  receiver.TaskNumber(projectId, []int{constant.TaskStatusCompleted, constant.TaskStatusArchived}),
-		Processing: receiver.TaskNumber(projectId, []int{constant.TaskStatusProcessing}),
+		Completed:  receiver.TaskNumber(projectId, constant.GetTaskFinishedCategories()),
+		Processing: receiver.TaskNumber(projectId, []int{constant.TaskCategoryOpen}),
 	}
 	// 任务延误数量
 	taskStatistics.FinishOnTime, taskStatistics.TimeoutCompletion = receiver.TaskDelayNumber(projectId)
@@ -620,8 +946,8 @@ func (receiver TaskService) TaskDelayNumber(projectId uint) (int64, int64) {
 		timeoutCompletionNumber int64
 	)
 
-	// 查询已完成的任务(包括已归档的)
-	list, err := receiver.repo.GetTasksByProject(projectId, []int{constant.TaskStatusCompleted, constant.TaskStatusArchived})
+	// 查询已完成分类的任务(包括已归档的)
+	list, err := receiver.repo.GetTasksByProject(projectId, constant.GetTaskFinishedCategories())
 	if err != nil {
 		_ = exception.ErrorHandle(err, response.DbQueryError, "任务延误数量方法查询任务列表错误：")
 		return 0, 0
@@ -729,7 +1055,7 @@ func (receiver TaskService) DailySituation(query dto.DailySituationQuery) ([]dto
 		}
 
 		// 当天未完成的任务
-		incompleteQuantity, err = receiver.repo.TaskNumber(query.ProjectId, []int{constant.TaskStatusProcessing})
+		incompleteQuantity, err = receiver.repo.TaskNumber(query.ProjectId, []int{constant.TaskCategoryOpen})
 		if err != nil {
 			return nil, exception.ErrorHandle(err, response.DbQueryError)
 		}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/auth"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/pkg/im"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// taskCommentMaxLength 评论内容的最大长度
+const taskCommentMaxLength = 10000
+
+type TaskCommentService struct {
+	Orm  *gorm.DB
+	ctx  *gin.Context
+	repo repo.TaskCommentRepo
+}
+
+func NewTaskCommentService(tx *gorm.DB, ctx *gin.Context) *TaskCommentService {
+	return &TaskCommentService{
+		Orm:  tx,  // 赋予ORM实例
+		ctx:  ctx, // 传递上下文
+		repo: data.NewTaskCommentRepo(tx, ctx),
+	}
+}
+
+// Add 发表评论或回复评论，通知提及的项目成员
+func (receiver TaskCommentService) Add(post dto.TaskCommentForm) (*repo.TaskComment, error) {
+	task, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Get(post.TaskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	currUser, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 回复的评论必须属于同一任务
+	if post.ParentId > 0 {
+		parent, err := receiver.repo.Get(post.ParentId)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.TaskCommentNotExist)
+		}
+		if parent.TaskId != task.ID || parent.DeleteTime > 0 {
+			return nil, exception.NewException(response.TaskCommentInvalid, "回复的评论不存在")
+		}
+	}
+
+	content, err := receiver.checkContent(post.Content)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := receiver.checkMentions(task.ProjectId, post.Mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &repo.TaskComment{
+		TaskId:   task.ID,
+		ParentId: post.ParentId,
+		Content:  content,
+		Mentions: receiver.encodeMentions(mentions),
+		Creator:  currUser.ID,
+	}
+	if err := receiver.repo.Create(comment); err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+
+	receiver.notify(task, comment, currUser, mentions)
+	comment.CreatorInfo = currUser
+	receiver.fill([]*repo.TaskComment{comment})
+	return comment, nil
+}
+
+// Update 编辑评论，只有评论人可以编辑，编辑前的内容保存到历史
+// 只通知新增提及的成员
+func (receiver TaskCommentService) Update(post dto.TaskCommentForm) (*repo.TaskComment, error) {
+	comment, task, currUser, err := receiver.check(post.ID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Creator != currUser.ID {
+		return nil, exception.NewException(response.TaskCommentNoPermission, "只能编辑自己的评论")
+	}
+
+	content, err := receiver.checkContent(post.Content)
+	if err != nil {
+		return nil, err
+	}
+	mentions, err := receiver.checkMentions(task.ProjectId, post.Mentions)
+	if err != nil {
+		return nil, err
+	}
+	oldMentions := receiver.decodeMentions(comment.Mentions)
+
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := receiver.saveHistory(tx, comment, constant.TaskCommentEdit, currUser.ID); err != nil {
+			return err
+		}
+		comment.Content = content
+		comment.Mentions = receiver.encodeMentions(mentions)
+		comment.EditTime = time.Now().UnixMilli()
+		return data.NewTaskCommentRepo(tx, receiver.ctx).UpdateFields(comment.ID, map[string]interface{}{
+			"content":   comment.Content,
+			"mentions":  comment.Mentions,
+			"edit_time": comment.EditTime,
+		})
+	})
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+
+	receiver.notify(task, comment, currUser, slice.Difference(mentions, oldMentions))
+	comment.CreatorInfo = currUser
+	receiver.fill([]*repo.TaskComment{comment})
+	return comment, nil
+}
+
+// Delete 删除评论，评论人与项目负责人可以删除
+// 只清空内容并记录删除时间，保留回复，删除前的内容保存到历史
+func (receiver TaskCommentService) Delete(id uint) error {
+	comment, task, currUser, err := receiver.check(id)
+	if err != nil {
+		return err
+	}
+	if comment.Creator != currUser.ID && !NewProjectMemberService(receiver.Orm, receiver.ctx).IsLeader(task.ProjectId, currUser.ID) {
+		return exception.NewException(response.TaskCommentNoPermission, "只能删除自己的评论")
+	}
+
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := receiver.saveHistory(tx, comment, constant.TaskCommentDelete, currUser.ID); err != nil {
+			return err
+		}
+		return data.NewTaskCommentRepo(tx, receiver.ctx).UpdateFields(comment.ID, map[string]interface{}{
+			"content":     "",
+			"mentions":    "",
+			"delete_time": time.Now().UnixMilli(),
+		})
+	})
+	return exception.ErrorHandle(err, response.DbExecuteError)
+}
+
+// List 获取任务的评论，按讨论串组织，回复在所回复评论的 Replies 中，只有项目成员可以查看
+func (receiver TaskCommentService) List(taskId uint) ([]*repo.TaskComment, error) {
+	task, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Get(taskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	if _, err := receiver.checkViewer(task.ProjectId); err != nil {
+		return nil, err
+	}
+
+	comments, err := receiver.repo.GetTaskComments(taskId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	nodes := make(map[uint]*repo.TaskComment, len(comments))
+	for i := range comments {
+		nodes[comments[i].ID] = &comments[i]
+	}
+	roots := make([]*repo.TaskComment, 0)
+	for i := range comments {
+		comment := &comments[i]
+		if parent, ok := nodes[comment.ParentId]; ok && comment.ParentId > 0 {
+			parent.Replies = append(parent.Replies, comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+
+	receiver.fill(slice.Map(comments, func(i int, _ repo.TaskComment) *repo.TaskComment {
+		return &comments[i]
+	}))
+	return roots, nil
+}
+
+// History 获取评论的编辑与删除历史，只有项目成员可以查看
+// 删除前的内容只有评论人与超级管理员可以查看
+func (receiver TaskCommentService) History(id uint) ([]repo.TaskCommentHistory, error) {
+	comment, err := receiver.repo.Get(id)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskCommentNotExist)
+	}
+	task, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Get(comment.TaskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	currUser, err := receiver.checkViewer(task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	histories, err := data.NewTaskCommentHistoryRepo(receiver.Orm, receiver.ctx).GetCommentHistories(id)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	if comment.Creator != currUser.ID && !auth.IsSuper(currUser) {
+		for i, item := range histories {
+			if item.Action == constant.TaskCommentDelete {
+				histories[i].Content = ""
+			}
+		}
+	}
+	return histories, nil
+}
+
+// checkViewer 当前用户是否可以查看项目中任务的评论，项目归档后仍然可以查看
+func (receiver TaskCommentService) checkViewer(projectId uint) (*repo.User, error) {
+	currUser, err := auth.CurrUser(receiver.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !NewProjectMemberService(receiver.Orm, receiver.ctx).IsMember(projectId, currUser.ID) {
+		return nil, exception.NewException(response.MemberNotInProject, "您不属于项目成员")
+	}
+	return currUser, nil
+}
+
+// check 获取未删除的评论与所属任务，并检查当前用户是否可以操作
+func (receiver TaskCommentService) check(id uint) (*repo.TaskComment, *repo.Task, *repo.User, error) {
+	comment, err := receiver.repo.Get(id)
+	if err != nil {
+		return nil, nil, nil, db.FirstQueryErrorHandle(err, response.TaskCommentNotExist)
+	}
+	if comment.DeleteTime > 0 {
+		return nil, nil, nil, exception.NewException(response.TaskCommentNotExist, "评论已删除")
+	}
+	task, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Get(comment.TaskId)
+	if err != nil {
+		return nil, nil, nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	currUser, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(task.ProjectId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return comment, task, currUser, nil
+}
+
+// checkContent 校验评论内容
+func (receiver TaskCommentService) checkContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if len(content) <= 0 {
+		return "", exception.NewException(response.TaskCommentInvalid, "评论内容不能为空")
+	}
+	if utf8.RuneCountInString(content) > taskCommentMaxLength {
+		return "", exception.NewException(response.TaskCommentInvalid, fmt.Sprintf("评论内容不能超过%d个字", taskCommentMaxLength))
+	}
+	return content, nil
+}
+
+// checkMentions 提及的用户必须是项目成员
+func (receiver TaskCommentService) checkMentions(projectId uint, mentions []uint64) ([]uint64, error) {
+	mentions = slice.Filter(slice.Unique(mentions), func(_ int, id uint64) bool {
+		return id > 0
+	})
+	if len(mentions) <= 0 {
+		return mentions, nil
+	}
+
+	members, err := data.NewProjectMemberRepo(receiver.Orm, receiver.ctx).GetProjectMembers(projectId, mentions)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	memberIds := slice.Map(members, func(_ int, item repo.ProjectMember) uint64 {
+		return item.UserId
+	})
+	for _, id := range mentions {
+		if !slice.Contain(memberIds, id) {
+			return nil, exception.NewException(response.TaskCommentInvalid, fmt.Sprintf("提及的用户[%d]不是项目成员", id))
+		}
+	}
+	return mentions, nil
+}
+
+// saveHistory 保存评论当前的内容到历史
+func (receiver TaskCommentService) saveHistory(tx *gorm.DB, comment *repo.TaskComment, action string, operator uint64) error {
+	return data.NewTaskCommentHistoryRepo(tx, receiver.ctx).Create(&repo.TaskCommentHistory{
+		CommentId: comment.ID,
+		Action:    action,
+		Content:   comment.Content,
+		Mentions:  comment.Mentions,
+		Operator:  operator,
+	})
+}
+
+// fill 填充评论提及的用户
+func (receiver TaskCommentService) fill(comments []*repo.TaskComment) {
+	mentions := make(map[uint][]uint64, len(comments))
+	userIds := make([]uint64, 0)
+	for _, comment := range comments {
+		mentions[comment.ID] = receiver.decodeMentions(comment.Mentions)
+		userIds = append(userIds, mentions[comment.ID]...)
+	}
+
+	users, err := data.NewUserRepo(receiver.Orm, receiver.ctx).GetUsers(slice.Unique(userIds))
+	if err != nil {
+		logrus.Errorf("评论提及的用户查询失败: %v", err)
+	}
+	for _, comment := range comments {
+		comment.MentionUsers = slice.Filter(users, func(_ int, user repo.User) bool {
+			return slice.Contain(mentions[comment.ID], user.ID)
+		})
+	}
+}
+
+// notify 通过网关通知提及的用户，跳过评论人自己，失败只记录日志
+func (receiver TaskCommentService) notify(task *repo.Task, comment *repo.TaskComment, currUser *repo.User, mentions []uint64) {
+	users := make([]string, 0, len(mentions))
+	for _, id := range mentions {
+		if id != currUser.ID {
+			users = append(users, strconv.FormatUint(id, 10))
+		}
+	}
+	if len(users) <= 0 {
+		return
+	}
+
+	err := im.SendUsers(users, map[string]interface{}{
+		"type":       "task_comment",
+		"event":      "mention",
+		"task_id":    task.ID,
+		"comment_id": comment.ID,
+		"title":      task.Title,
+		"message":    fmt.Sprintf("%s在任务[%s]的评论中提到了你", currUser.UserNickname, task.Title),
+	})
+	if err != nil {
+		logrus.Errorf("任务评论[%d]提及通知发送失败: %v", comment.ID, err)
+	}
+}
+
+// encodeMentions 提及的用户ID转为json数组字符串
+func (receiver TaskCommentService) encodeMentions(mentions []uint64) string {
+	if len(mentions) <= 0 {
+		return ""
+	}
+	b, _ := json.Marshal(mentions)
+	return string(b)
+}
+
+// decodeMentions 解析提及的用户ID
+func (receiver TaskCommentService) decodeMentions(mentions string) []uint64 {
+	var ids []uint64
+	if len(mentions) > 0 {
+		_ = json.Unmarshal([]byte(mentions), &ids)
+	}
+	return ids
+}
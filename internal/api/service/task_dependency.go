@@ -0,0 +1,187 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type TaskDependencyService struct {
+	Orm  *gorm.DB
+	ctx  *gin.Context
+	repo repo.TaskDependencyRepo
+}
+
+func NewTaskDependencyService(tx *gorm.DB, ctx *gin.Context) *TaskDependencyService {
+	return &TaskDependencyService{
+		Orm:  tx,  // 赋予ORM实例
+		ctx:  ctx, // 传递上下文
+		repo: data.NewTaskDependencyRepo(tx, ctx),
+	}
+}
+
+// Add 添加依赖，TaskId 依赖 DependId
+func (receiver TaskDependencyService) Add(post dto.TaskDependencyForm) (*repo.TaskDependency, error) {
+	if post.TaskId == post.DependId {
+		return nil, exception.NewException(response.TaskDependencyCycle, "任务不能依赖自身")
+	}
+
+	taskRepo := data.NewTaskRepo(receiver.Orm, receiver.ctx)
+	task, err := taskRepo.Get(post.TaskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	depend, err := taskRepo.Get(post.DependId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	if task.ProjectId != depend.ProjectId {
+		return nil, exception.NewException(response.TaskDependencyInvalid, "只能依赖同一项目的任务")
+	}
+
+	currUser, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if receiver.repo.Exist(task.ID, depend.ID) {
+		return nil, exception.NewException(response.TaskDependencyExist)
+	}
+
+	// 被依赖的任务直接或间接依赖当前任务时会形成环
+	cycle, err := receiver.reachable(depend.ID, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, exception.NewException(response.TaskDependencyCycle)
+	}
+
+	dependency := &repo.TaskDependency{
+		TaskId:   task.ID,
+		DependId: depend.ID,
+		Creator:  currUser.ID,
+	}
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := data.NewTaskDependencyRepo(tx, receiver.ctx).Create(dependency); err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
+		// 记录日志
+		_, err := NewTaskLogService(tx, receiver.ctx).Add(dto.TaskLogForm{
+			TaskId:      task.ID,
+			OperateType: constant.TaskOperatorDependency,
+			Message:     fmt.Sprintf("添加了前置任务[%s]", depend.Title),
+		})
+		return err
+	})
+	return dependency, err
+}
+
+// Delete 删除依赖
+func (receiver TaskDependencyService) Delete(id uint) error {
+	dependency, err := receiver.repo.Get(id)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskDependencyNotExist)
+	}
+
+	taskRepo := data.NewTaskRepo(receiver.Orm, receiver.ctx)
+	task, err := taskRepo.Get(dependency.TaskId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(task.ProjectId); err != nil {
+		return err
+	}
+
+	// 前置任务可能已被删除
+	dependTitle := ""
+	if depend, err := taskRepo.Get(dependency.DependId); err == nil {
+		dependTitle = depend.Title
+	}
+
+	return receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := data.NewTaskDependencyRepo(tx, receiver.ctx).Delete(dependency.ID); err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
+		// 记录日志
+		_, err := NewTaskLogService(tx, receiver.ctx).Add(dto.TaskLogForm{
+			TaskId:      task.ID,
+			OperateType: constant.TaskOperatorDependency,
+			Message:     fmt.Sprintf("移除了前置任务[%s]", dependTitle),
+		})
+		return err
+	})
+}
+
+// List 获取任务的前置任务与后续任务
+func (receiver TaskDependencyService) List(taskId uint) (*repo.TaskDependencyList, error) {
+	blockers, err := receiver.repo.GetBlockers(taskId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	dependents, err := receiver.repo.GetDependents(taskId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	return &repo.TaskDependencyList{Blockers: blockers, Dependents: dependents}, nil
+}
+
+// ProcessingBlockers 获取任务仍在进行中的前置任务
+func (receiver TaskDependencyService) ProcessingBlockers(taskId uint) ([]*repo.Task, error) {
+	blockers, err := receiver.repo.GetBlockers(taskId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	tasks := make([]*repo.Task, 0)
+	for _, blocker := range blockers {
+		if blocker.Depend != nil && blocker.Depend.Status == constant.TaskCategoryOpen {
+			tasks = append(tasks, blocker.Depend)
+		}
+	}
+	return tasks, nil
+}
+
+// reachable 从 from 沿依赖关系是否能到达 to
+func (receiver TaskDependencyService) reachable(from, to uint) (bool, error) {
+	visited := map[uint]bool{from: true}
+	current := []uint{from}
+	for len(current) > 0 {
+		ids, err := receiver.repo.GetDependIds(current)
+		if err != nil {
+			return false, exception.ErrorHandle(err, response.DbQueryError)
+		}
+
+		next := make([]uint, 0, len(ids))
+		for _, id := range ids {
+			if id == to {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				next = append(next, id)
+			}
+		}
+		current = next
+	}
+	return false, nil
+}
+
+// blockerTitles 前置任务标题，用于提示
+func blockerTitles(tasks []*repo.Task) string {
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return strings.Join(titles, "、")
+}
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TaskFieldService struct {
+	Orm  *gorm.DB
+	ctx  *gin.Context
+	repo repo.TaskFieldRepo
+}
+
+// taskFieldValues 校验后的自定义字段值，值为空字符串表示清除
+type taskFieldValues struct {
+	fields []repo.TaskField // 项目的所有字段，按排序
+	values map[uint]string
+}
+
+func NewTaskFieldService(tx *gorm.DB, ctx *gin.Context) *TaskFieldService {
+	return &TaskFieldService{
+		Orm:  tx,  // 赋予ORM实例
+		ctx:  ctx, // 传递上下文
+		repo: data.NewTaskFieldRepo(tx, ctx),
+	}
+}
+
+// Add 新增自定义字段，只有项目负责人可以操作
+func (receiver TaskFieldService) Add(post dto.TaskFieldForm) (*repo.TaskField, error) {
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckLeader(post.ProjectId); err != nil {
+		return nil, err
+	}
+
+	field := &repo.TaskField{ProjectId: post.ProjectId, Type: post.Type}
+	if err := receiver.fill(field, post); err != nil {
+		return nil, err
+	}
+
+	if err := receiver.repo.Create(field); err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+	return field, nil
+}
+
+// Update 编辑自定义字段，字段类型与所属项目不能修改，只有项目负责人可以操作
+func (receiver TaskFieldService) Update(post dto.TaskFieldForm) (*repo.TaskField, error) {
+	field, err := receiver.repo.Get(post.ID)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskFieldNotExist)
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckLeader(field.ProjectId); err != nil {
+		return nil, err
+	}
+	if field.Type != post.Type {
+		return nil, exception.NewException(response.TaskFieldInvalid, "字段类型不能修改")
+	}
+
+	if err := receiver.fill(field, post); err != nil {
+		return nil, err
+	}
+
+	if err := receiver.repo.Save(field); err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+	return field, nil
+}
+
+// Delete 删除自定义字段，同时删除所有任务中该字段的值，只有项目负责人可以操作
+func (receiver TaskFieldService) Delete(id uint) error {
+	field, err := receiver.repo.Get(id)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskFieldNotExist)
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckLeader(field.ProjectId); err != nil {
+		return err
+	}
+
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := data.NewTaskFieldRepo(tx, receiver.ctx).Delete(field.ID); err != nil {
+			return err
+		}
+		return data.NewTaskFieldValueRepo(tx, receiver.ctx).DeleteByField(field.ID)
+	})
+	return exception.ErrorHandle(err, response.DbExecuteError)
+}
+
+// List 获取项目的所有自定义字段
+func (receiver TaskFieldService) List(projectId uint) ([]repo.TaskField, error) {
+	fields, err := receiver.repo.GetProjectFields(projectId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	return fields, nil
+}
+
+// Types 获取所有字段类型
+func (receiver TaskFieldService) Types() map[string]string {
+	return constant.GetTaskFieldTypes()
+}
+
+// fill 校验表单并填充到字段
+func (receiver TaskFieldService) fill(field *repo.TaskField, post dto.TaskFieldForm) error {
+	if _, ok := constant.GetTaskFieldTypes()[post.Type]; !ok {
+		return exception.NewException(response.TaskFieldInvalid, "不支持的字段类型")
+	}
+	post.Name = strings.TrimSpace(post.Name)
+	if len(post.Name) <= 0 {
+		return exception.NewException(response.TaskFieldInvalid, "字段名称不能为空")
+	}
+	if receiver.repo.ExistName(field.ProjectId, post.Name, field.ID) {
+		return exception.NewException(response.TaskFieldInvalid, fmt.Sprintf("字段[%s]已存在", post.Name))
+	}
+
+	// 选项
+	options := ""
+	if post.Type == constant.TaskFieldSelect || post.Type == constant.TaskFieldMultiSelect {
+		if len(post.Options) <= 0 {
+			return exception.NewException(response.TaskFieldInvalid, "至少需要一个选项")
+		}
+		for _, option := range post.Options {
+			if len(strings.TrimSpace(option)) <= 0 {
+				return exception.NewException(response.TaskFieldInvalid, "选项不能为空")
+			}
+		}
+		if len(slice.Unique(post.Options)) != len(post.Options) {
+			return exception.NewException(response.TaskFieldInvalid, "选项不能重复")
+		}
+		b, _ := json.Marshal(post.Options)
+		options = string(b)
+	}
+
+	field.Name = post.Name
+	field.Options = options
+	field.Required = 0
+	if post.Required {
+		field.Required = 1
+	}
+	field.Sort = post.Sort
+	return nil
+}
+
+// checkValues 校验任务的自定义字段值
+// create 为true时所有必填字段都需要提供值，否则只校验提供的字段
+func (receiver TaskFieldService) checkValues(projectId uint, values map[uint]interface{}, create bool) (*taskFieldValues, error) {
+	fields, err := receiver.repo.GetProjectFields(projectId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	result := &taskFieldValues{
+		fields: fields,
+		values: make(map[uint]string, len(values)),
+	}
+	fieldMap := make(map[uint]repo.TaskField, len(fields))
+	for _, field := range fields {
+		fieldMap[field.ID] = field
+	}
+
+	for fieldId, value := range values {
+		field, ok := fieldMap[fieldId]
+		if !ok {
+			return nil, exception.NewException(response.TaskFieldNotExist, fmt.Sprintf("项目中不存在字段[%d]", fieldId))
+		}
+		v, err := receiver.normalize(field, value)
+		if err != nil {
+			return nil, err
+		}
+		result.values[fieldId] = v
+	}
+
+	// 必填字段
+	for _, field := range fields {
+		if field.Required != 1 {
+			continue
+		}
+		v, ok := result.values[field.ID]
+		if (create && !ok) || (ok && len(v) <= 0) {
+			return nil, exception.NewException(response.TaskFieldValueInvalid, fmt.Sprintf("[%s]不能为空", field.Name))
+		}
+	}
+	return result, nil
+}
+
+// normalize 校验字段值，并转换为保存的字符串
+func (receiver TaskFieldService) normalize(field repo.TaskField, value interface{}) (string, error) {
+	invalid := exception.NewException(response.TaskFieldValueInvalid, fmt.Sprintf("[%s]的值不合法", field.Name))
+	if value == nil {
+		return "", nil
+	}
+	if s, ok := value.(string); ok && len(strings.TrimSpace(s)) <= 0 {
+		return "", nil
+	}
+
+	switch field.Type {
+	case constant.TaskFieldText:
+		s, ok := value.(string)
+		if !ok {
+			return "", invalid
+		}
+		return s, nil
+	case constant.TaskFieldNumber:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return "", invalid
+			}
+			f = parsed
+		default:
+			return "", invalid
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case constant.TaskFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return "", invalid
+		}
+		t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+		if err != nil {
+			return "", invalid
+		}
+		return t.Format(time.DateOnly), nil
+	case constant.TaskFieldSelect:
+		s, ok := value.(string)
+		if !ok || !slice.Contain(receiver.options(field), s) {
+			return "", invalid
+		}
+		return s, nil
+	case constant.TaskFieldMultiSelect:
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case string:
+			// 已保存的值为json数组字符串
+			if err := json.Unmarshal([]byte(v), &items); err != nil {
+				return "", invalid
+			}
+		default:
+			return "", invalid
+		}
+
+		options := receiver.options(field)
+		selected := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !slice.Contain(options, s) {
+				return "", invalid
+			}
+			selected = append(selected, s)
+		}
+		selected = slice.Unique(selected)
+		if len(selected) <= 0 {
+			return "", nil
+		}
+		b, _ := json.Marshal(selected)
+		return string(b), nil
+	case constant.TaskFieldUser:
+		var uid uint64
+		switch v := value.(type) {
+		case float64:
+			uid = uint64(v)
+		case string:
+			parsed, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return "", invalid
+			}
+			uid = parsed
+		default:
+			return "", invalid
+		}
+		// 只能选择项目成员
+		if !NewProjectMemberService(receiver.Orm, receiver.ctx).IsMember(field.ProjectId, uid) {
+			return "", exception.NewException(response.TaskFieldValueInvalid, fmt.Sprintf("[%s]只能选择项目成员", field.Name))
+		}
+		return strconv.FormatUint(uid, 10), nil
+	}
+	return "", invalid
+}
+
+// options 单选、多选字段的选项
+func (receiver TaskFieldService) options(field repo.TaskField) []string {
+	var options []string
+	_ = json.Unmarshal([]byte(field.Options), &options)
+	return options
+}
+
+// saveValues 保存任务的自定义字段值，并记录变更日志
+func (receiver TaskFieldService) saveValues(taskId uint, fieldValues *taskFieldValues) error {
+	if fieldValues == nil || len(fieldValues.values) <= 0 {
+		return nil
+	}
+
+	valueRepo := data.NewTaskFieldValueRepo(receiver.Orm, receiver.ctx)
+	existing, err := valueRepo.GetTaskValues(taskId)
+	if err != nil {
+		return err
+	}
+	oldValues := make(map[uint]string, len(existing))
+	for _, item := range existing {
+		oldValues[item.FieldId] = item.Value
+	}
+
+	changes := make([]string, 0)
+	logChanges := make([]dto.TaskLogChange, 0)
+	cleared := make([]uint, 0)
+	for _, field := range fieldValues.fields {
+		value, ok := fieldValues.values[field.ID]
+		if !ok || value == oldValues[field.ID] {
+			continue
+		}
+
+		change := dto.TaskLogChange{Field: fmt.Sprintf("%s%d", logFieldCustomPrefix, field.ID), Label: field.Name}
+		old, exist := oldValues[field.ID]
+		if exist {
+			change.Before = receiver.display(field, old)
+		}
+		if len(value) <= 0 {
+			cleared = append(cleared, field.ID)
+			changes = append(changes, fmt.Sprintf("清除了[%s]", field.Name))
+			logChanges = append(logChanges, change)
+			continue
+		}
+		err := valueRepo.Save(&repo.TaskFieldValue{TaskId: taskId, FieldId: field.ID, Value: value})
+		if err != nil {
+			return err
+		}
+		change.After = receiver.display(field, value)
+		logChanges = append(logChanges, change)
+		if exist {
+			changes = append(changes, fmt.Sprintf("将[%s]从[%s]修改为[%s]", field.Name, change.Before, change.After))
+		} else {
+			changes = append(changes, fmt.Sprintf("设置[%s]为[%s]", field.Name, change.After))
+		}
+	}
+	if err := valueRepo.DeleteByFields(taskId, cleared); err != nil {
+		return err
+	}
+	if len(changes) <= 0 {
+		return nil
+	}
+
+	// 记录日志
+	_, err = NewTaskLogService(receiver.Orm, receiver.ctx).Add(dto.TaskLogForm{
+		TaskId:      taskId,
+		OperateType: constant.TaskOperatorField,
+		Message:     strings.Join(changes, "；"),
+		Changes:     logChanges,
+	})
+	return err
+}
+
+// TaskValues 获取任务的自定义字段值
+func (receiver TaskFieldService) TaskValues(taskId uint) ([]repo.TaskFieldValue, error) {
+	values, err := data.NewTaskFieldValueRepo(receiver.Orm, receiver.ctx).GetTaskValues(taskId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	for i, item := range values {
+		if item.Field != nil {
+			values[i].Display = receiver.display(*item.Field, item.Value)
+		}
+	}
+	return values, nil
+}
+
+// display 用于展示的字段值
+func (receiver TaskFieldService) display(field repo.TaskField, value string) string {
+	switch field.Type {
+	case constant.TaskFieldMultiSelect:
+		var items []string
+		if err := json.Unmarshal([]byte(value), &items); err == nil {
+			return strings.Join(items, "、")
+		}
+	case constant.TaskFieldUser:
+		uid, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			break
+		}
+		if user, err := data.NewUserRepo(receiver.Orm, receiver.ctx).GetUser(uid); err == nil {
+			return user.UserNickname
+		}
+	}
+	return value
+}
@@ -9,6 +9,7 @@ import (
 	"VitaTaskGo/internal/repo"
 	"VitaTaskGo/pkg/exception"
 	"VitaTaskGo/pkg/response"
+	"encoding/json"
 	"github.com/duke-git/lancet/v2/maputil"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
@@ -72,6 +73,14 @@ func (receiver TaskLogService) Add(dto dto.TaskLogForm) (*repo.TaskLog, error) {
 		OperateTime: dto.OperateTime,
 		Message:     dto.Message,
 	}
+	// 变更明细
+	if len(dto.Changes) > 0 {
+		changes, err := json.Marshal(dto.Changes)
+		if err != nil {
+			return nil, exception.ErrorHandle(err, response.SystemFail)
+		}
+		logData.Changes = string(changes)
+	}
 
 	return logData, receiver.repo.Create(logData)
 }
@@ -83,6 +92,7 @@ func (receiver TaskLogService) List(query dto.TaskLogQuery) *dto.PagedResult[rep
 		logs = make([]repo.TaskLog, 0)
 		_ = exception.ErrorHandle(err, response.DbQueryError, "任务日志列表查询失败: ")
 	}
+	receiver.renderChanges(logs)
 
 	return pkg.PagedResult[repo.TaskLog](logs, total, int64(query.Page))
 }
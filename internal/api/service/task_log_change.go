@@ -0,0 +1,231 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/repo"
+	"encoding/json"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"strings"
+	"time"
+)
+
+// 任务日志变更明细的字段名
+const (
+	logFieldProject      = "project_id"
+	logFieldGroup        = "group_id"
+	logFieldParent       = "parent_id"
+	logFieldTitle        = "title"
+	logFieldDescribe     = "describe"
+	logFieldLevel        = "level"
+	logFieldPlanTime     = "plan_time"
+	logFieldLeader       = "leader"
+	logFieldCollaborator = "collaborator"
+	logFieldStatus       = "status"
+	logFieldCustomPrefix = "field:" // 自定义字段，后接字段ID
+)
+
+// logTextMaxLength 渲染时单个值的最大长度，超出部分省略
+const logTextMaxLength = 50
+
+// taskChanges 对比任务基础字段修改前后的值
+func taskChanges(task *repo.Task, post dto.TaskCreateForm, startDate, endDate int64) []dto.TaskLogChange {
+	changes := make([]dto.TaskLogChange, 0)
+	add := func(field, label string, before, after interface{}) {
+		if before != after {
+			changes = append(changes, dto.TaskLogChange{Field: field, Label: label, Before: before, After: after})
+		}
+	}
+
+	add(logFieldProject, "项目", task.ProjectId, post.ProjectId)
+	add(logFieldGroup, "任务组", task.GroupId, post.GroupId)
+	add(logFieldParent, "父任务", task.ParentId, post.ParentId)
+	add(logFieldTitle, "标题", task.Title, post.Title)
+	add(logFieldDescribe, "描述", task.Describe, post.Describe)
+	add(logFieldLevel, "紧急度", task.Level, post.Level)
+	if task.StartDate != startDate || task.EndDate != endDate {
+		changes = append(changes, dto.TaskLogChange{
+			Field:  logFieldPlanTime,
+			Label:  "计划时间",
+			Before: []int64{task.StartDate, task.EndDate},
+			After:  []int64{startDate, endDate},
+		})
+	}
+	return changes
+}
+
+// memberChange 成员的变更，没有变化时返回nil
+func memberChange(field, label string, before, after []uint64) *dto.TaskLogChange {
+	added := slice.Difference(after, before)
+	removed := slice.Difference(before, after)
+	if len(added) <= 0 && len(removed) <= 0 {
+		return nil
+	}
+	return &dto.TaskLogChange{
+		Field:   field,
+		Label:   label,
+		Before:  before,
+		After:   after,
+		Added:   added,
+		Removed: removed,
+	}
+}
+
+// changeLabels 变更的字段名称，用于日志信息
+func changeLabels(changes []dto.TaskLogChange) string {
+	labels := make([]string, len(changes))
+	for i, change := range changes {
+		labels[i] = change.Label
+	}
+	return strings.Join(labels, "、")
+}
+
+// logRenderer 渲染日志的变更明细，缓存查询过的名称
+type logRenderer struct {
+	service  TaskLogService
+	users    map[uint64]string
+	projects map[uint]string
+	groups   map[uint]string
+	tasks    map[uint]string
+}
+
+// renderChanges 解析日志的变更明细，并渲染为可读文本
+func (receiver TaskLogService) renderChanges(logs []repo.TaskLog) {
+	renderer := &logRenderer{
+		service:  receiver,
+		users:    make(map[uint64]string),
+		projects: make(map[uint]string),
+		groups:   make(map[uint]string),
+		tasks:    make(map[uint]string),
+	}
+
+	// 先解析并批量查询涉及的成员
+	userIds := make([]uint64, 0)
+	for i, item := range logs {
+		if len(item.Changes) <= 0 {
+			continue
+		}
+		if err := json.Unmarshal([]byte(item.Changes), &logs[i].ChangeList); err != nil {
+			continue
+		}
+		for _, change := range logs[i].ChangeList {
+			userIds = append(userIds, change.Added...)
+			userIds = append(userIds, change.Removed...)
+		}
+	}
+	users, _ := data.NewUserRepo(receiver.Orm, receiver.ctx).GetUsers(slice.Unique(userIds))
+	for _, user := range users {
+		renderer.users[user.ID] = user.UserNickname
+	}
+
+	for i := range logs {
+		for j, change := range logs[i].ChangeList {
+			logs[i].ChangeList[j].Text = renderer.text(change)
+		}
+	}
+}
+
+// text 单个变更的可读文本
+func (r *logRenderer) text(change dto.TaskLogChange) string {
+	if change.Field == logFieldLeader || change.Field == logFieldCollaborator {
+		parts := make([]string, 0, 2)
+		if len(change.Added) > 0 {
+			parts = append(parts, fmt.Sprintf("新增[%s]", r.userNames(change.Added)))
+		}
+		if len(change.Removed) > 0 {
+			parts = append(parts, fmt.Sprintf("移除[%s]", r.userNames(change.Removed)))
+		}
+		return fmt.Sprintf("[%s]%s", change.Label, strings.Join(parts, "，"))
+	}
+
+	return fmt.Sprintf("将[%s]从[%s]修改为[%s]", change.Label, r.value(change.Field, change.Before), r.value(change.Field, change.After))
+}
+
+// value 单个值的可读文本
+func (r *logRenderer) value(field string, v interface{}) string {
+	if v == nil {
+		return "空"
+	}
+
+	switch field {
+	case logFieldProject:
+		id := uint(logNumber(v))
+		if _, ok := r.projects[id]; !ok {
+			r.projects[id] = fmt.Sprintf("#%d", id)
+			if project, err := data.NewProjectRepo(r.service.Orm, r.service.ctx).GetProject(id); err == nil {
+				r.projects[id] = project.Name
+			}
+		}
+		return r.projects[id]
+	case logFieldGroup:
+		id := uint(logNumber(v))
+		if id <= 0 {
+			return "无"
+		}
+		if _, ok := r.groups[id]; !ok {
+			r.groups[id] = fmt.Sprintf("#%d", id)
+			if group, err := data.NewTaskGroupRepo(r.service.Orm, r.service.ctx).Get(id); err == nil {
+				r.groups[id] = group.Name
+			}
+		}
+		return r.groups[id]
+	case logFieldParent:
+		id := uint(logNumber(v))
+		if id <= 0 {
+			return "无"
+		}
+		if _, ok := r.tasks[id]; !ok {
+			r.tasks[id] = fmt.Sprintf("#%d", id)
+			if task, err := data.NewTaskRepo(r.service.Orm, r.service.ctx).Get(id); err == nil {
+				r.tasks[id] = task.Title
+			}
+		}
+		return r.tasks[id]
+	case logFieldPlanTime:
+		times, ok := v.([]interface{})
+		if !ok || len(times) < 2 || logNumber(times[0]) <= 0 {
+			return "未设置"
+		}
+		return fmt.Sprintf(
+			"%s ~ %s",
+			time.UnixMilli(int64(logNumber(times[0]))).Format(time.DateOnly),
+			time.UnixMilli(int64(logNumber(times[1]))).Format(time.DateOnly),
+		)
+	}
+
+	s := fmt.Sprint(v)
+	if len(s) <= 0 {
+		return "空"
+	}
+	if runes := []rune(s); len(runes) > logTextMaxLength {
+		return string(runes[:logTextMaxLength]) + "..."
+	}
+	return s
+}
+
+// userNames 成员昵称，用户不存在时使用ID
+func (r *logRenderer) userNames(ids []uint64) string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		name, ok := r.users[id]
+		if !ok {
+			name = fmt.Sprintf("#%d", id)
+		}
+		names[i] = name
+	}
+	return strings.Join(names, "、")
+}
+
+// logNumber 解析json中的数字
+func logNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case uint:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/pkg/recurrence"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"VitaTaskGo/pkg/state"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+)
+
+// recurrencePreviewNum 详情中预览的生成时间数量
+const recurrencePreviewNum = 5
+
+type TaskRecurrenceService struct {
+	Orm  *gorm.DB
+	ctx  *gin.Context
+	repo repo.TaskRecurrenceRepo
+}
+
+func NewTaskRecurrenceService(tx *gorm.DB, ctx *gin.Context) *TaskRecurrenceService {
+	return &TaskRecurrenceService{
+		Orm:  tx,  // 赋予ORM实例
+		ctx:  ctx, // 传递上下文
+		repo: data.NewTaskRecurrenceRepo(tx, ctx),
+	}
+}
+
+// Set 设置任务的重复规则，已存在时更新
+func (receiver TaskRecurrenceService) Set(post dto.TaskRecurrenceForm) (*repo.TaskRecurrence, error) {
+	task, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Get(post.TaskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+
+	currUser, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(task.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := recurrence.Parse(post.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// 开始日期与结束日期
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	if len(post.StartDate) > 0 {
+		start, err = time.ParseInLocation(time.DateOnly, post.StartDate, time.Local)
+		if err != nil {
+			return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "开始日期格式错误")
+		}
+	}
+	var endDate int64
+	if len(post.EndDate) > 0 {
+		end, err := time.ParseInLocation(time.DateOnly, post.EndDate, time.Local)
+		if err != nil {
+			return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "结束日期格式错误")
+		}
+		if end.Before(start) {
+			return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "结束日期不能早于开始日期")
+		}
+		// 结束日期当天仍然生成
+		endDate = end.AddDate(0, 0, 1).UnixMilli() - 1
+	}
+
+	recurrenceData, err := receiver.repo.GetByTask(task.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	if recurrenceData == nil || recurrenceData.ID <= 0 {
+		recurrenceData = &repo.TaskRecurrence{TaskId: task.ID}
+	}
+	recurrenceData.ProjectId = task.ProjectId
+	recurrenceData.Rule = post.Rule
+	recurrenceData.StartDate = start.UnixMilli()
+	recurrenceData.EndDate = endDate
+	recurrenceData.Creator = currUser.ID
+	// 从开始日期与今天中较晚的一天开始生成
+	from := start
+	if from.Before(today) {
+		from = today
+	}
+	recurrenceData.NextTime = receiver.nextTime(rule, recurrenceData, from.AddDate(0, 0, -1))
+
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := data.NewTaskRecurrenceRepo(tx, receiver.ctx).Save(recurrenceData); err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
+		// 记录日志
+		_, err := NewTaskLogService(tx, receiver.ctx).Add(dto.TaskLogForm{
+			TaskId:      task.ID,
+			OperateType: constant.TaskOperatorRecurrence,
+			Message:     fmt.Sprintf("设置了重复规则[%s]", recurrenceData.Rule),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recurrenceData.NextTimes = receiver.preview(rule, recurrenceData)
+	return recurrenceData, nil
+}
+
+// Delete 删除任务的重复规则，已生成的任务不受影响
+func (receiver TaskRecurrenceService) Delete(taskId uint) error {
+	recurrenceData, err := receiver.repo.GetByTask(taskId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskRecurrenceNotExist)
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckMember(recurrenceData.ProjectId); err != nil {
+		return err
+	}
+
+	return receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		if err := data.NewTaskRecurrenceRepo(tx, receiver.ctx).Delete(recurrenceData.ID); err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
+		// 记录日志
+		_, err := NewTaskLogService(tx, receiver.ctx).Add(dto.TaskLogForm{
+			TaskId:      taskId,
+			OperateType: constant.TaskOperatorRecurrence,
+			Message:     "取消了重复规则",
+		})
+		return err
+	})
+}
+
+// Detail 获取任务的重复规则，包含接下来几次生成任务的时间
+func (receiver TaskRecurrenceService) Detail(taskId uint) (*repo.TaskRecurrence, error) {
+	recurrenceData, err := receiver.repo.GetByTask(taskId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.TaskRecurrenceNotExist)
+	}
+
+	rule, err := recurrence.Parse(recurrenceData.Rule)
+	if err == nil {
+		recurrenceData.NextTimes = receiver.preview(rule, recurrenceData)
+	}
+	return recurrenceData, nil
+}
+
+// Generate 为到期的重复规则生成任务
+// 错过多次时只按最近一次生成一个任务，项目归档时跳过本次生成
+// 以下一次生成时间为条件认领本次生成，已被其它实例认领时直接返回
+func (receiver TaskRecurrenceService) Generate(recurrenceData repo.TaskRecurrence, now time.Time) error {
+	rule, err := recurrence.Parse(recurrenceData.Rule)
+	if err != nil {
+		// 规则已无法解析，不再生成
+		_, _ = receiver.repo.Claim(recurrenceData.ID, recurrenceData.NextTime, map[string]interface{}{"next_time": 0})
+		return err
+	}
+
+	// 错过的多次只保留最近的一次
+	occurrence := time.UnixMilli(recurrenceData.NextTime)
+	for {
+		next := rule.Next(time.UnixMilli(recurrenceData.StartDate), occurrence)
+		if next.IsZero() || next.After(now) {
+			break
+		}
+		occurrence = next
+	}
+	values := map[string]interface{}{
+		"next_time": receiver.nextTime(rule, &recurrenceData, occurrence),
+	}
+
+	template, err := data.NewTaskRepo(receiver.Orm, receiver.ctx).Detail(recurrenceData.TaskId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 模板任务已删除，不再生成
+			values["next_time"] = 0
+			_, err = receiver.repo.Claim(recurrenceData.ID, recurrenceData.NextTime, values)
+			return err
+		}
+		return err
+	}
+
+	// 项目已归档，跳过本次生成
+	if data.NewProjectRepo(receiver.Orm, receiver.ctx).Archived(template.ProjectId) {
+		_, err = receiver.repo.Claim(recurrenceData.ID, recurrenceData.NextTime, values)
+		return err
+	}
+
+	post := receiver.taskForm(template, occurrence)
+	err = receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		// 先认领本次生成，认领的行锁保持到事务结束
+		values["last_time"] = now.UnixMilli()
+		values["count"] = gorm.Expr("`count` + ?", 1)
+		claimed, err := data.NewTaskRecurrenceRepo(tx, nil).Claim(recurrenceData.ID, recurrenceData.NextTime, values)
+		if err != nil || !claimed {
+			return err
+		}
+
+		// 以设置规则的用户身份创建任务
+		creator, err := data.NewUserRepo(tx, nil).GetUser(recurrenceData.Creator)
+		if err != nil {
+			return db.FirstQueryErrorHandle(err, response.UserNotFound)
+		}
+		task, err := NewTaskService(tx, nil).CreateAs(creator, post)
+		if err != nil {
+			return err
+		}
+		if err := data.NewTaskRepo(tx, nil).UpdateField(task.ID, "recurrence_id", recurrenceData.ID); err != nil {
+			return err
+		}
+
+		// 记录日志
+		_, err = NewTaskLogService(tx, nil).Add(dto.TaskLogForm{
+			TaskId:      task.ID,
+			OperateType: constant.TaskOperatorRecurrence,
+			Operator:    recurrenceData.Creator,
+			Message:     fmt.Sprintf("由任务[%s]的重复规则自动生成", template.Title),
+		})
+		return err
+	})
+	if err != nil {
+		// 生成失败时同样跳过本次，避免每次扫描重复失败，并在模板任务中记录
+		claimed, _ := receiver.repo.Claim(recurrenceData.ID, recurrenceData.NextTime, map[string]interface{}{"next_time": values["next_time"]})
+		if claimed {
+			_ = data.NewTaskLogRepo(receiver.Orm, nil).Create(&repo.TaskLog{
+				TaskId:      template.ID,
+				OperateType: constant.TaskOperatorRecurrence,
+				Operator:    recurrenceData.Creator,
+				OperateTime: now.UnixMilli(),
+				Message:     fmt.Sprintf("重复规则未能生成%s的任务，已跳过: %s", occurrence.Format(time.DateOnly), response.Error(err).Message),
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+// taskForm 以模板任务生成新任务的表单，计划时间平移到生成日期并保持原有时长
+func (receiver TaskRecurrenceService) taskForm(template *repo.Task, occurrence time.Time) dto.TaskCreateForm {
+	post := dto.TaskCreateForm{
+		ProjectId: template.ProjectId,
+		GroupId:   template.GroupId,
+		ParentId:  template.ParentId,
+		Title:     template.Title,
+		Describe:  template.Describe,
+		Level:     template.Level,
+	}
+
+	// 负责人与协助人
+	if leader := taskLeader(template); leader != nil {
+		post.Leader = leader.UserId
+	}
+	for _, member := range template.Member {
+		if state.NewModifier(int(member.Role)).Exist(constant.TaskMember) {
+			post.Collaborator = append(post.Collaborator, member.UserId)
+		}
+	}
+
+	// 自定义字段
+	if values, err := NewTaskFieldService(receiver.Orm, receiver.ctx).TaskValues(template.ID); err == nil {
+		post.Fields = make(map[uint]interface{}, len(values))
+		for _, item := range values {
+			post.Fields[item.FieldId] = item.Value
+		}
+	}
+
+	// 计划时间
+	end := occurrence
+	if template.StartDate > 0 && template.EndDate > 0 {
+		start, finish := time.UnixMilli(template.StartDate), time.UnixMilli(template.EndDate)
+		days := int(finish.Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())).Hours() / 24)
+		end = occurrence.AddDate(0, 0, days)
+	}
+	post.PlanTime = []string{occurrence.Format(time.DateOnly), end.Format(time.DateOnly)}
+	return post
+}
+
+// nextTime 获取 after 之后下一次生成任务的时间，超过结束日期时返回0
+func (receiver TaskRecurrenceService) nextTime(rule *recurrence.Rule, recurrenceData *repo.TaskRecurrence, after time.Time) int64 {
+	next := rule.Next(time.UnixMilli(recurrenceData.StartDate), after)
+	if next.IsZero() {
+		return 0
+	}
+	if recurrenceData.EndDate > 0 && next.UnixMilli() > recurrenceData.EndDate {
+		return 0
+	}
+	return next.UnixMilli()
+}
+
+// preview 预览接下来几次生成任务的时间
+func (receiver TaskRecurrenceService) preview(rule *recurrence.Rule, recurrenceData *repo.TaskRecurrence) []int64 {
+	times := make([]int64, 0, recurrencePreviewNum)
+	next := recurrenceData.NextTime
+	for next > 0 && len(times) < recurrencePreviewNum {
+		times = append(times, next)
+		next = receiver.nextTime(rule, recurrenceData, time.UnixMilli(next))
+	}
+	return times
+}
+
+// RunTaskRecurrenceScheduler 定时为到期的重复规则生成任务，会阻塞当前协程
+// interval 小于等于0时直接返回
+func RunTaskRecurrenceScheduler(tx *gorm.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		CheckTaskRecurrence(tx)
+	}
+}
+
+// CheckTaskRecurrence 扫描到期的重复规则并生成任务
+func CheckTaskRecurrence(tx *gorm.DB) {
+	defer func() {
+		if err := recover(); err != nil {
+			logrus.Errorln("重复任务扫描异常:", err)
+		}
+	}()
+
+	now := time.Now()
+	recurrenceService := NewTaskRecurrenceService(tx, nil)
+	list, err := recurrenceService.repo.GetDue(now.UnixMilli())
+	if err != nil {
+		logrus.Errorln("重复任务扫描失败:", err)
+		return
+	}
+
+	for _, item := range list {
+		if err := recurrenceService.Generate(item, now); err != nil {
+			logrus.Errorf("任务[%d]的重复规则生成失败: %v", item.TaskId, err)
+		}
+	}
+}
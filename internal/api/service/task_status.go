@@ -0,0 +1,205 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type TaskStatusService struct {
+	Orm  *gorm.DB
+	ctx  *gin.Context
+	repo repo.TaskStatusRepo
+}
+
+func NewTaskStatusService(tx *gorm.DB, ctx *gin.Context) *TaskStatusService {
+	return &TaskStatusService{
+		Orm:  tx,  // 赋予ORM实例
+		ctx:  ctx, // 传递上下文
+		repo: data.NewTaskStatusRepo(tx, ctx),
+	}
+}
+
+// Save 新增或编辑项目的任务状态，只有项目负责人可以操作
+func (receiver TaskStatusService) Save(post dto.TaskStatusForm) (*repo.TaskStatus, error) {
+	status := &repo.TaskStatus{ProjectId: post.ProjectId}
+	if post.ID > 0 {
+		var err error
+		status, err = receiver.repo.Get(post.ID)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.TaskStatusNotExist)
+		}
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckLeader(status.ProjectId); err != nil {
+		return nil, err
+	}
+
+	// 校验
+	if _, ok := constant.GetTaskCategories()[post.Category]; !ok {
+		return nil, exception.NewException(response.TaskStatusInvalid, "状态分类不存在")
+	}
+	post.Name = strings.TrimSpace(post.Name)
+	if len(post.Name) <= 0 {
+		return nil, exception.NewException(response.TaskStatusInvalid, "状态名称不能为空")
+	}
+	if receiver.repo.ExistName(status.ProjectId, post.Name, status.ID) {
+		return nil, exception.NewException(response.TaskStatusInvalid, fmt.Sprintf("状态[%s]已存在", post.Name))
+	}
+	if post.IsDefault && post.Category != constant.TaskCategoryOpen {
+		return nil, exception.NewException(response.TaskStatusInvalid, "初始状态必须属于未完成分类")
+	}
+	// 已有任务使用时不能修改分类，否则任务的分类与状态不一致
+	if status.ID > 0 && status.Category != post.Category && data.NewTaskRepo(receiver.Orm, receiver.ctx).ExistStatus(status.ID) {
+		return nil, exception.NewException(response.TaskStatusInUse, "已有任务使用该状态，不能修改分类")
+	}
+
+	// 流转的目标状态必须属于同一项目
+	transitions := ""
+	if post.Transitions != nil {
+		statuses, err := receiver.repo.GetProjectStatuses(status.ProjectId)
+		if err != nil {
+			return nil, exception.ErrorHandle(err, response.DbQueryError)
+		}
+		ids := slice.Map(statuses, func(_ int, item repo.TaskStatus) uint {
+			return item.ID
+		})
+		post.Transitions = slice.Unique(post.Transitions)
+		for _, id := range post.Transitions {
+			if id == status.ID || !slice.Contain(ids, id) {
+				return nil, exception.NewException(response.TaskStatusInvalid, fmt.Sprintf("流转的目标状态[%d]不合法", id))
+			}
+		}
+		b, _ := json.Marshal(post.Transitions)
+		transitions = string(b)
+	}
+
+	status.Name = post.Name
+	status.Category = post.Category
+	status.Color = post.Color
+	status.Sort = post.Sort
+	status.Transitions = transitions
+	status.IsDefault = 0
+	if post.IsDefault {
+		status.IsDefault = 1
+	}
+
+	err := receiver.Orm.Transaction(func(tx *gorm.DB) error {
+		statusRepo := data.NewTaskStatusRepo(tx, receiver.ctx)
+		if err := statusRepo.Save(status); err != nil {
+			return err
+		}
+		// 一个项目只有一个初始状态
+		if status.IsDefault == 1 {
+			return statusRepo.ClearDefault(status.ProjectId, status.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+	return status, nil
+}
+
+// Delete 删除项目的任务状态，有任务使用时不能删除，只有项目负责人可以操作
+func (receiver TaskStatusService) Delete(id uint) error {
+	status, err := receiver.repo.Get(id)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskStatusNotExist)
+	}
+	if _, err := NewProjectMemberService(receiver.Orm, receiver.ctx).CheckLeader(status.ProjectId); err != nil {
+		return err
+	}
+	if data.NewTaskRepo(receiver.Orm, receiver.ctx).ExistStatus(status.ID) {
+		return exception.NewException(response.TaskStatusInUse, "已有任务使用该状态，不能删除")
+	}
+
+	return exception.ErrorHandle(receiver.repo.Delete(status.ID), response.DbExecuteError)
+}
+
+// List 获取项目的所有任务状态
+func (receiver TaskStatusService) List(projectId uint) ([]repo.TaskStatus, error) {
+	statuses, err := receiver.repo.GetProjectStatuses(projectId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	return statuses, nil
+}
+
+// resolve 获取任务要变更到的状态
+// statusId 为空时使用分类，项目有自定义状态时取该分类排序最靠前的状态
+// 该分类下没有自定义状态时返回nil，直接使用分类，调用方需要清空任务的 status_id
+func (receiver TaskStatusService) resolve(projectId, statusId uint, category int) (*repo.TaskStatus, error) {
+	if statusId > 0 {
+		status, err := receiver.repo.Get(statusId)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.TaskStatusNotExist)
+		}
+		if status.ProjectId != projectId {
+			return nil, exception.NewException(response.TaskStatusNotExist, "状态不属于任务所在的项目")
+		}
+		return status, nil
+	}
+
+	if _, ok := constant.GetTaskCategories()[category]; !ok {
+		return nil, exception.NewException(response.TaskStatusNotExist)
+	}
+	status, err := receiver.repo.GetFirstByCategory(projectId, category)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	return status, nil
+}
+
+// checkTransition 任务是否可以从当前状态流转到目标状态
+func (receiver TaskStatusService) checkTransition(task *repo.Task, target *repo.TaskStatus) error {
+	// 旧任务没有自定义状态，不限制
+	if task.StatusId <= 0 || task.StatusId == target.ID {
+		return nil
+	}
+	current, err := receiver.repo.Get(task.StatusId)
+	if err != nil || len(current.Transitions) <= 0 {
+		return nil
+	}
+
+	var transitions []uint
+	_ = json.Unmarshal([]byte(current.Transitions), &transitions)
+	if !slice.Contain(transitions, target.ID) {
+		return exception.NewException(
+			response.TaskStatusTransition,
+			fmt.Sprintf("不允许从[%s]流转到[%s]", current.Name, target.Name),
+		)
+	}
+	return nil
+}
+
+// statusIdByCategory 任务移动到其它项目时，按分类获取新项目中对应的状态，项目没有自定义状态时返回0
+func (receiver TaskStatusService) statusIdByCategory(projectId uint, category int) uint {
+	status, err := receiver.repo.GetFirstByCategory(projectId, category)
+	if err != nil {
+		return 0
+	}
+	return status.ID
+}
+
+// defaultStatus 新任务的初始状态，项目没有自定义状态时返回nil
+func (receiver TaskStatusService) defaultStatus(projectId uint) *repo.TaskStatus {
+	status, err := receiver.repo.GetDefault(projectId)
+	if err != nil {
+		return nil
+	}
+	return status
+}
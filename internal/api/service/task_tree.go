@@ -0,0 +1,194 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/constant"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"VitaTaskGo/pkg/state"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// descendants 获取这些任务所有层级的子任务，按层级顺序返回
+func (receiver TaskService) descendants(tx *gorm.DB, rootIds []uint) ([]repo.Task, error) {
+	taskRepo := data.NewTaskRepo(tx, receiver.ctx)
+
+	list := make([]repo.Task, 0)
+	// 防止数据出现环
+	visited := make(map[uint]bool, len(rootIds))
+	for _, id := range rootIds {
+		visited[id] = true
+	}
+
+	current := rootIds
+	for len(current) > 0 {
+		children, err := taskRepo.GetChildren(current)
+		if err != nil {
+			return nil, exception.ErrorHandle(err, response.DbQueryError)
+		}
+
+		next := make([]uint, 0, len(children))
+		for _, child := range children {
+			if visited[child.ID] {
+				continue
+			}
+			visited[child.ID] = true
+			list = append(list, child)
+			next = append(next, child.ID)
+		}
+		current = next
+	}
+	return list, nil
+}
+
+// fillChildren 填充子任务，并计算子任务进度
+func (receiver TaskService) fillChildren(tasks []*repo.Task) error {
+	if len(tasks) <= 0 {
+		return nil
+	}
+
+	nodes := make(map[uint]*repo.Task, len(tasks))
+	rootIds := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		nodes[task.ID] = task
+		rootIds = append(rootIds, task.ID)
+	}
+
+	list, err := receiver.descendants(receiver.Db, rootIds)
+	if err != nil {
+		return err
+	}
+	for i := range list {
+		child := &list[i]
+		child.PlanTime = []int64{child.StartDate, child.EndDate}
+		child.Leader = taskLeader(child)
+		nodes[child.ID] = child
+	}
+	// 按层级顺序返回，父任务一定已经在 nodes 中
+	for i := range list {
+		if parent, ok := nodes[list[i].ParentId]; ok {
+			parent.Children = append(parent.Children, &list[i])
+		}
+	}
+
+	for _, task := range tasks {
+		rollUpProgress(task)
+	}
+	return nil
+}
+
+// rollUpProgress 统计所有层级子任务的完成数量，返回子任务总数与已完成数量
+func rollUpProgress(task *repo.Task) (int, int) {
+	total, completed := 0, 0
+	for _, child := range task.Children {
+		t, c := rollUpProgress(child)
+		total += t + 1
+		completed += c
+		if child.Status != constant.TaskCategoryOpen {
+			completed++
+		}
+	}
+
+	task.SubtaskTotal = total
+	task.SubtaskCompleted = completed
+	task.Progress = 0
+	if total > 0 {
+		task.Progress = completed * 100 / total
+	}
+	return total, completed
+}
+
+// taskLeader 从任务成员中取出负责人
+func taskLeader(task *repo.Task) *repo.TaskMember {
+	for _, member := range task.Member {
+		if state.NewModifier(int(member.Role)).Exist(constant.TaskLeader) {
+			return member
+		}
+	}
+	return nil
+}
+
+// checkParent 校验父任务，task 为nil时表示新建任务
+func (receiver TaskService) checkParent(task *repo.Task, parentId, projectId uint) error {
+	if parentId <= 0 {
+		return nil
+	}
+
+	parent, err := receiver.repo.Get(parentId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskParentInvalid)
+	}
+	if parent.ProjectId != projectId {
+		return exception.NewException(response.TaskParentInvalid, "父任务必须属于同一项目")
+	}
+	if task == nil {
+		return nil
+	}
+
+	// 不能移动到自身或自己的子任务下
+	if parent.ID == task.ID {
+		return exception.NewException(response.TaskParentInvalid, "不能移动到自身下")
+	}
+	list, err := receiver.descendants(receiver.Db, []uint{task.ID})
+	if err != nil {
+		return err
+	}
+	for _, child := range list {
+		if child.ID == parent.ID {
+			return exception.NewException(response.TaskParentInvalid, "不能移动到自己的子任务下")
+		}
+	}
+	return nil
+}
+
+// moveChildren 将所有子任务移动到父任务所在的项目与任务组
+// 子任务的成员与对话跟随任务，不需要处理，自定义字段值属于原项目，一起删除
+func (receiver TaskService) moveChildren(tx *gorm.DB, task *repo.Task, projectId, groupId uint) error {
+	children, err := receiver.descendants(tx, []uint{task.ID})
+	if err != nil || len(children) <= 0 {
+		return err
+	}
+
+	// 按状态分类分组，使用新项目中同一分类的状态
+	ids := make([]uint, 0, len(children))
+	categoryIds := make(map[int][]uint)
+	for _, child := range children {
+		ids = append(ids, child.ID)
+		categoryIds[int(child.Status)] = append(categoryIds[int(child.Status)], child.ID)
+	}
+	taskRepo := data.NewTaskRepo(tx, receiver.ctx)
+	taskStatusService := NewTaskStatusService(tx, receiver.ctx)
+	for category, categoryTaskIds := range categoryIds {
+		err = taskRepo.UpdateFieldsByIds(categoryTaskIds, map[string]interface{}{
+			"project_id": projectId,
+			"group_id":   groupId,
+			"status_id":  taskStatusService.statusIdByCategory(projectId, category),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// 记录日志
+	taskLogService := NewTaskLogService(tx, receiver.ctx)
+	taskFieldValueRepo := data.NewTaskFieldValueRepo(tx, receiver.ctx)
+	for _, id := range ids {
+		// 原项目的自定义字段值不再有效
+		if err := taskFieldValueRepo.DeleteByTask(id); err != nil {
+			return err
+		}
+		_, err := taskLogService.Add(dto.TaskLogForm{
+			TaskId:      id,
+			OperateType: constant.TaskOperatorUpdate,
+			Message:     fmt.Sprintf("随父任务[%s]移动到其它项目", task.Title),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
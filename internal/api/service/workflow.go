@@ -4,14 +4,16 @@ import (
 	"VitaTaskGo/internal/api/data"
 	"VitaTaskGo/internal/api/model/dto"
 	"VitaTaskGo/internal/pkg"
+	"VitaTaskGo/internal/pkg/auth"
+	"VitaTaskGo/internal/pkg/constant"
 	"VitaTaskGo/internal/pkg/workflow"
 	"VitaTaskGo/internal/repo"
 	"VitaTaskGo/pkg/db"
 	"VitaTaskGo/pkg/exception"
 	"VitaTaskGo/pkg/response"
 	"VitaTaskGo/pkg/time_tool"
+	"encoding/json"
 	"github.com/duke-git/lancet/v2/convertor"
-	"github.com/duke-git/lancet/v2/maputil"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -39,11 +41,35 @@ func (r *WorkflowService) Initiate(post dto.WorkflowInitiateDto) error {
 		err    error
 	)
 
+	// 当前登录用户作为操作人
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	// 关联的项目与任务
+	projectId, taskId, err := r.relation(post.ProjectId, post.TaskId)
+	if err != nil {
+		return err
+	}
+	if projectId > 0 {
+		projectRepo := data.NewProjectRepo(r.Db, r.ctx)
+		// 是否属于项目成员
+		if !data.NewProjectMemberRepo(r.Db, r.ctx).InProject(projectId, user.ID, nil) {
+			return exception.NewException(response.MemberNotInProject, "您不属于项目成员")
+		}
+		// 项目是否归档
+		if projectRepo.Archived(projectId) {
+			return exception.NewException(response.ProjectArchived)
+		}
+	}
+
 	// 创建引擎对象
-	engine, err = workflow.Create(r.Db, r.ctx, post.TypeId)
+	engine, err = workflow.Create(r.Db, r.ctx, user, post.TypeId)
 	if err != nil {
 		return exception.ErrorHandle(err, response.SystemFail)
 	}
+	engine.SetRelation(projectId, taskId)
 
 	// 将struct转换成map
 	toMap, err := convertor.StructToMap(post)
@@ -53,6 +79,7 @@ func (r *WorkflowService) Initiate(post dto.WorkflowInitiateDto) error {
 
 	// 设置表单数据
 	engine.SetFormData(toMap)
+	engine.SetData(post.Data)
 	// 发起工作流
 	err = engine.Initiate()
 	return exception.ErrorHandle(err, response.SystemFail)
@@ -64,8 +91,14 @@ func (r *WorkflowService) ExamineApprove(post dto.WorkflowExamineApproveDto) err
 		err    error
 	)
 
+	// 当前登录用户作为操作人
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+
 	// 创建引擎对象
-	engine, err = workflow.Open(r.Db, r.ctx, post.WorkflowId)
+	engine, err = workflow.Open(r.Db, r.ctx, user, post.WorkflowId)
 	if err != nil {
 		return exception.ErrorHandle(err, response.SystemFail)
 	}
@@ -78,15 +111,309 @@ func (r *WorkflowService) ExamineApprove(post dto.WorkflowExamineApproveDto) err
 
 	// 设置表单数据
 	engine.SetFormData(toMap)
+	engine.SetData(post.Data)
 	// 执行审批
 	err = engine.ExamineApprove()
 	return exception.ErrorHandle(err, response.SystemFail)
 }
 
+// Simulate 模拟工作流流转，返回经过的节点与每个节点的操作人，不写入任何数据
+func (r *WorkflowService) Simulate(post dto.WorkflowSimulateDto) (*workflow.SimulateResult, error) {
+	initiator, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if post.Initiator > 0 {
+		initiator, err = data.NewUserRepo(r.Db, r.ctx).GetUser(post.Initiator)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.UserNotFound)
+		}
+	}
+
+	projectId, taskId, err := r.relation(post.ProjectId, post.TaskId)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := workflow.Create(r.Db, r.ctx, initiator, post.TypeId)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	}
+	engine.SetRelation(projectId, taskId)
+	// 默认模拟正在编辑的节点，方便发布前预览
+	if err := engine.UseVersion(post.Version); err != nil {
+		return nil, err
+	}
+
+	engine.SetFormData(post.Data)
+	engine.SetData(post.Data)
+	result, err := engine.Simulate()
+	return result, exception.ErrorHandle(err, response.SystemFail)
+}
+
+// relation 校验工作流关联的项目与任务，关联任务时项目为空则使用任务所在的项目
+func (r *WorkflowService) relation(projectId, taskId uint) (uint, uint, error) {
+	if taskId > 0 {
+		task, err := data.NewTaskRepo(r.Db, r.ctx).Get(taskId)
+		if err != nil {
+			return 0, 0, db.FirstQueryErrorHandle(err, response.TaskNotExist)
+		}
+		if projectId > 0 && projectId != task.ProjectId {
+			return 0, 0, exception.NewException(response.WorkflowRelationInvalid, "任务不属于该项目")
+		}
+		projectId = task.ProjectId
+	}
+
+	if projectId > 0 && !data.NewProjectRepo(r.Db, r.ctx).Exist(projectId) {
+		return 0, 0, exception.NewException(response.ProjectNotExist)
+	}
+	return projectId, taskId, nil
+}
+
+// Withdraw 发起人撤回工作流
+func (r *WorkflowService) Withdraw(post dto.WorkflowWithdrawDto) error {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	engine, err := workflow.Open(r.Db, r.ctx, user, post.WorkflowId)
+	if err != nil {
+		return exception.ErrorHandle(err, response.SystemFail)
+	}
+
+	engine.SetFormDataField("comment", post.Comment)
+	err = engine.Withdraw()
+	return exception.ErrorHandle(err, response.SystemFail)
+}
+
+// DelegationList 当前用户的委托规则
+func (r *WorkflowService) DelegationList() ([]repo.WorkflowDelegation, error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := data.NewWorkflowDelegationRepo(r.Db, r.ctx).GetUserDelegations(user.ID)
+	return list, exception.ErrorHandle(err, response.DbQueryError)
+}
+
+// DelegationAdd 添加委托规则
+func (r *WorkflowService) DelegationAdd(post dto.WorkflowDelegationDto) (*repo.WorkflowDelegation, error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	one := &repo.WorkflowDelegation{
+		UserId:   user.ID,
+		Nickname: user.UserNickname,
+	}
+	if err = r.fillDelegation(one, post); err != nil {
+		return nil, err
+	}
+
+	err = data.NewWorkflowDelegationRepo(r.Db, r.ctx).Create(one)
+	return one, exception.ErrorHandle(err, response.WorkflowDelegationSaveFail)
+}
+
+// DelegationUpdate 修改委托规则，只能修改自己的
+func (r *WorkflowService) DelegationUpdate(post dto.WorkflowDelegationDto) (*repo.WorkflowDelegation, error) {
+	one, err := r.getOwnDelegation(post.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.fillDelegation(one, post); err != nil {
+		return nil, err
+	}
+
+	err = data.NewWorkflowDelegationRepo(r.Db, r.ctx).Save(one)
+	return one, exception.ErrorHandle(err, response.WorkflowDelegationSaveFail)
+}
+
+// DelegationDelete 删除委托规则，只能删除自己的
+// 已经生成的代理操作人不受影响
+func (r *WorkflowService) DelegationDelete(id uint) error {
+	one, err := r.getOwnDelegation(id)
+	if err != nil {
+		return err
+	}
+
+	err = data.NewWorkflowDelegationRepo(r.Db, r.ctx).Delete(one.ID)
+	return exception.ErrorHandle(err, response.DbExecuteError)
+}
+
+func (r *WorkflowService) getOwnDelegation(id uint) (*repo.WorkflowDelegation, error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	one, err := data.NewWorkflowDelegationRepo(r.Db, r.ctx).Get(id)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowDelegationNotExist)
+	}
+	if one.UserId != user.ID {
+		return nil, exception.NewException(response.WorkflowDelegationNotExist)
+	}
+	return one, nil
+}
+
+// fillDelegation 校验并填充委托规则
+func (r *WorkflowService) fillDelegation(one *repo.WorkflowDelegation, post dto.WorkflowDelegationDto) error {
+	if post.DelegateId == one.UserId {
+		return exception.NewException(response.WorkflowDelegationInvalid, "不能委托给自己")
+	}
+
+	// 代理人
+	delegate, err := data.NewUserRepo(r.Db, r.ctx).GetUser(post.DelegateId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.UserNotFound)
+	}
+
+	// 有效期
+	if len(post.Date) != 2 {
+		return exception.NewException(response.WorkflowDelegationInvalid, "有效期必须包含开始日期与结束日期")
+	}
+	dateRange, err := time_tool.ParseStartEndTimeToUnix(post.Date, time.DateOnly, "milli")
+	if err != nil {
+		return exception.ErrorHandle(err, response.TimeParseFail)
+	}
+	if dateRange[0] > dateRange[1] {
+		return exception.NewException(response.WorkflowDelegationInvalid, "开始日期不能晚于结束日期")
+	}
+
+	// 工作流类型
+	one.TypeIds = ""
+	one.TypeIdList = nil
+	if len(post.TypeIds) > 0 {
+		typeIds := slice.Unique(post.TypeIds)
+		typeIdsJson, err := json.Marshal(typeIds)
+		if err != nil {
+			return exception.ErrorHandle(err, response.SystemFail)
+		}
+		one.TypeIds = string(typeIdsJson)
+		one.TypeIdList = typeIds
+	}
+
+	one.DelegateId = delegate.ID
+	one.DelegateNickname = delegate.UserNickname
+	one.StartTime = dateRange[0]
+	one.EndTime = dateRange[1]
+	one.Remark = post.Remark
+	return nil
+}
+
+// checkViewer 当前用户是否可以查看工作流
+// 发起人、当前或曾经的操作人、抄送人与超级管理员可以查看
+func (r *WorkflowService) checkViewer(workflowId uint) error {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+	one, err := data.NewWorkflowRepo(r.Db, r.ctx).Get(workflowId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.WorkflowDataNotExist)
+	}
+
+	if one.Promoter == user.ID || auth.IsSuper(user) ||
+		data.NewWorkflowOperatorRepo(r.Db, r.ctx).ExistOperator(workflowId, user.ID) ||
+		data.NewWorkflowLogRepo(r.Db, r.ctx).ExistOperator(workflowId, user.ID) ||
+		data.NewWorkflowCcRepo(r.Db, r.ctx).Exist(workflowId, user.ID) {
+		return nil
+	}
+	return exception.NewException(response.WorkflowViewDenied)
+}
+
+// Data 获取工作流最新的附加数据
+func (r *WorkflowService) Data(workflowId uint) (*repo.WorkflowData, error) {
+	if err := r.checkViewer(workflowId); err != nil {
+		return nil, err
+	}
+	one, err := data.NewWorkflowDataRepo(r.Db, r.ctx).GetLatest(workflowId)
+	return one, db.FirstQueryErrorHandle(err, response.WorkflowDataNotExist)
+}
+
+// DataVersions 获取工作流附加数据的所有版本
+func (r *WorkflowService) DataVersions(workflowId uint) ([]repo.WorkflowData, error) {
+	if err := r.checkViewer(workflowId); err != nil {
+		return nil, err
+	}
+	l, err := data.NewWorkflowDataRepo(r.Db, r.ctx).GetVersions(workflowId)
+	return l, exception.ErrorHandle(err, response.DbQueryError)
+}
+
+// DataUpdate 修改工作流附加数据
+func (r *WorkflowService) DataUpdate(post dto.WorkflowDataUpdateDto) error {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	// 创建引擎对象
+	engine, err := workflow.Open(r.Db, r.ctx, user, post.WorkflowId)
+	if err != nil {
+		return exception.ErrorHandle(err, response.SystemFail)
+	}
+
+	engine.SetData(post.Data)
+	err = engine.AmendData()
+	return exception.ErrorHandle(err, response.SystemFail)
+}
+
+// Log 工作流日志时间线
+func (r *WorkflowService) Log(workflowId uint) ([]repo.WorkflowLog, error) {
+	if err := r.checkViewer(workflowId); err != nil {
+		return nil, err
+	}
+	l, err := data.NewWorkflowLogRepo(r.Db, r.ctx).GetTimeline(workflowId)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	// 操作类型名称
+	for i, item := range l {
+		l[i].ActionText = workflow.LogActionMap[item.Action]
+	}
+	return l, nil
+}
+
+// ToDo 当前用户的待办工作流
+func (r *WorkflowService) ToDo(query dto.WorkflowListQueryDto) (*dto.PagedResult[repo.Workflow], error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query.TodoUser = user.ID
+	return r.PageList(query)
+}
+
+// Handled 当前用户的已办工作流
+func (r *WorkflowService) Handled(query dto.WorkflowListQueryDto) (*dto.PagedResult[repo.Workflow], error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query.HandledUser = user.ID
+	query.HandledActions = []string{
+		workflow.LogActionNext,
+		workflow.LogActionOverrule,
+		workflow.LogActionJump,
+		workflow.LogActionReject,
+		workflow.LogActionCancel,
+		workflow.LogActionAddSigner,
+		workflow.LogActionTransfer,
+		workflow.LogActionSignOpinion,
+	}
+	return r.PageList(query)
+}
+
 // PageList 分页列表
 func (r *WorkflowService) PageList(query dto.WorkflowListQueryDto) (*dto.PagedResult[repo.Workflow], error) {
 	workflowRepo := data.NewWorkflowRepo(r.Db, r.ctx)
-	workflowNodeRepo := data.NewWorkflowNodeRepo(r.Db, r.ctx)
 	workflowTypeRepo := data.NewWorkflowTypeRepo(r.Db, r.ctx)
 
 	// 获取所有非系统级的工作流类型
@@ -112,19 +439,49 @@ func (r *WorkflowService) PageList(query dto.WorkflowListQueryDto) (*dto.PagedRe
 		return pkg.PagedResult[repo.Workflow](nil, 0, int64(query.Page)), exception.ErrorHandle(err, response.DbQueryError, "列表查询失败: ")
 	}
 
-	statusNames := maputil.Keys(workflow.StatusMap)
+	versions := make(versionCache)
 
 	for i, item := range l {
+		// 并行审批时为进行中的分支所在节点，否则为当前节点
+		activeNodes := make(map[int]bool)
+		for _, token := range item.Tokens {
+			if token.Waiting == 0 {
+				activeNodes[token.Node] = true
+			}
+		}
+		parallel := len(item.Tokens) > 0
+		if !parallel {
+			activeNodes[item.Node] = true
+		}
+
+		// 只保留当前节点的操作人
+		operators := make([]repo.WorkflowOperator, 0, len(item.Operator))
+		for _, operator := range item.Operator {
+			if activeNodes[operator.Node] {
+				operators = append(operators, operator)
+			}
+		}
+		l[i].Operator = operators
+
 		// 获取节点数据
-		node, err := workflowNodeRepo.GetAppointNode(item.TypeId, item.Node)
+		node, err := r.workflowNode(versions, item, item.Node)
 		if err == nil {
 			l[i].NodeInfo = node
+			// 会签进度
+			if item.Status == workflow.StatusRunning && !parallel {
+				l[i].VoteProgress = workflow.GetVoteProgress(node, operators)
+			}
 		}
 
-		// 给状态赋值
-		if len(statusNames)-1 > item.Status {
-			l[i].StatusText = statusNames[item.Status]
+		// 并行分支所在的节点
+		for _, token := range item.Tokens {
+			if branchNode, err := r.workflowNode(versions, item, token.Node); err == nil {
+				l[i].BranchNodes = append(l[i].BranchNodes, branchNode)
+			}
 		}
+
+		// 给状态赋值
+		l[i].StatusText = workflow.StatusName(item.Status)
 	}
 
 	return pkg.PagedResult(l, total, int64(query.Page)), exception.ErrorHandle(err, response.DbQueryError, "列表查询失败: ")
@@ -136,6 +493,11 @@ func (r *WorkflowService) TypeAdd(post dto.WorkflowTypeDto) (*repo.WorkflowType,
 		return nil, exception.NewException(response.WorkflowTypeOnlyNameEmpty)
 	}
 
+	// 校验类型设置
+	if err := r.checkType(post); err != nil {
+		return nil, err
+	}
+
 	workflowTypeRepo := data.NewWorkflowTypeRepo(r.Db, r.ctx)
 	// 检查 OnlyName 是否有重复
 	if workflowTypeRepo.ExistByOnlyName(post.OnlyName) {
@@ -145,20 +507,96 @@ func (r *WorkflowService) TypeAdd(post dto.WorkflowTypeDto) (*repo.WorkflowType,
 
 	// 创建新对象
 	newData := &repo.WorkflowType{
-		Name:       post.Name,
-		OrgId:      post.OrgId,
-		OnlyName:   post.OnlyName,
-		Illustrate: post.Illustrate,
+		OnlyName: post.OnlyName,
 	}
+	r.fillType(newData, post)
+
+	saveErr := workflowTypeRepo.Create(newData)
+	return newData, exception.ErrorHandle(saveErr, response.WorkflowTypeCreateFail)
+}
+
+// checkType 校验工作流类型设置
+func (r *WorkflowService) checkType(post dto.WorkflowTypeDto) error {
+	// 校验表单结构
+	if _, err := workflow.ParseFormSchema(post.FormSchema); err != nil {
+		return err
+	}
+	// 校验任务审批状态，进行中的任务不需要审批
+	if post.TaskGateStatus != 0 {
+		if _, ok := constant.GetTaskStatus()[post.TaskGateStatus]; !ok || post.TaskGateStatus == constant.TaskStatusProcessing {
+			return exception.NewException(response.WorkflowTaskGateInvalid)
+		}
+	}
+	// 校验编号模板
+	return r.checkSerialTemplate(post)
+}
+
+// fillType 将表单数据填充到工作流类型，不修改 OnlyName
+func (r *WorkflowService) fillType(one *repo.WorkflowType, post dto.WorkflowTypeDto) {
+	one.Name = post.Name
+	one.OrgId = post.OrgId
+	one.Illustrate = post.Illustrate
+	one.FormSchema = post.FormSchema
+	one.SerialPrefix = post.SerialPrefix
+	one.SerialDateFormat = post.SerialDateFormat
+	one.SerialPadding = post.SerialPadding
+	one.TaskGateStatus = post.TaskGateStatus
 	// 是否系统级
 	if post.System {
-		newData.System = 1
+		one.System = 1
+	} else {
+		one.System = 0
+	}
+	// 编号是否独立计数
+	if post.SerialIndependent {
+		one.SerialIndependent = 1
 	} else {
-		newData.System = 0
+		one.SerialIndependent = 0
 	}
+}
 
-	saveErr := workflowTypeRepo.Create(newData)
-	return newData, exception.ErrorHandle(saveErr, response.WorkflowTypeCreateFail)
+// checkSerialTemplate 校验工作流类型的编号模板
+func (r *WorkflowService) checkSerialTemplate(post dto.WorkflowTypeDto) error {
+	if post.SerialPadding < 0 || post.SerialPadding > 20 {
+		return exception.NewException(response.WorkflowTypeSerialInvalid, "序号长度必须在0到20之间")
+	}
+	if len(post.SerialPrefix) > 32 || len(post.SerialDateFormat) > 32 {
+		return exception.NewException(response.WorkflowTypeSerialInvalid, "编号前缀或日期格式过长")
+	}
+	if post.SerialDateFormat != "" && !r.checkSerialDateFormat(post.SerialDateFormat) {
+		return exception.NewException(response.WorkflowTypeSerialInvalid, "日期格式不合法，需要包含年份且不能包含时分秒")
+	}
+	// 独立计数时必须有前缀，否则会与全局计数的编号重复
+	if post.SerialIndependent && post.SerialPrefix == "" {
+		return exception.NewException(response.WorkflowTypeSerialInvalid, "独立计数的工作流类型必须设置编号前缀")
+	}
+	return nil
+}
+
+// checkSerialDateFormat 校验编号的日期格式
+// 同一天内的日期必须相同，且已经过去的日期不能再次出现，否则序号重置后会生成重复的编号
+func (r *WorkflowService) checkSerialDateFormat(layout string) bool {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+	// 不能包含时分秒
+	if start.Format(layout) != start.Add(23*time.Hour+59*time.Minute+59*time.Second).Format(layout) {
+		return false
+	}
+	// 逐日检查两年内的日期，变化过的日期不能再出现
+	seen := map[string]bool{}
+	last := ""
+	for day := start; day.Year() < 2002; day = day.AddDate(0, 0, 1) {
+		date := day.Format(layout)
+		if date == last {
+			continue
+		}
+		if seen[date] {
+			return false
+		}
+		seen[date] = true
+		last = date
+	}
+	// 日期必须随时间变化
+	return len(seen) > 1
 }
 
 func (r *WorkflowService) TypeUpdate(post dto.WorkflowTypeDto) (*repo.WorkflowType, error) {
@@ -170,17 +608,14 @@ func (r *WorkflowService) TypeUpdate(post dto.WorkflowTypeDto) (*repo.WorkflowTy
 		return nil, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
 	}
 
-	// 不允许修改OnlyName
-	one.Name = post.Name
-	one.OrgId = post.OrgId
-	one.Illustrate = post.Illustrate
-	// 是否系统级
-	if post.System {
-		one.System = 1
-	} else {
-		one.System = 0
+	// 校验类型设置
+	if err := r.checkType(post); err != nil {
+		return nil, err
 	}
 
+	// 不允许修改OnlyName
+	r.fillType(one, post)
+
 	saveErr := workflowTypeRepo.Save(one)
 	return one, exception.ErrorHandle(saveErr, response.WorkflowTypeUpdateFail)
 }
@@ -212,6 +647,9 @@ func (r *WorkflowService) TypeList(query dto.WorkflowTypeQueryDto) (*dto.PagedRe
 	}
 
 	l, total, err := workflowTypeRepo.PageList(queryBo)
+	if err == nil {
+		err = r.fillOutdatedRunning(l)
+	}
 
 	return pkg.PagedResult(l, total, int64(query.Page)), exception.ErrorHandle(err, response.DbQueryError, "列表查询失败: ")
 }
@@ -228,7 +666,34 @@ func (r *WorkflowService) TypeDelete(id uint) error {
 
 func (r *WorkflowService) TypeDetail(id uint) (*repo.WorkflowType, error) {
 	one, err := data.NewWorkflowTypeRepo(r.Db, r.ctx).Get(id)
-	return one, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	}
+
+	l := []repo.WorkflowType{*one}
+	if err = r.fillOutdatedRunning(l); err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+	return &l[0], nil
+}
+
+// TypeSchema 根据唯一标志获取工作流类型的表单结构
+func (r *WorkflowService) TypeSchema(onlyName string) ([]workflow.FormField, error) {
+	one, err := data.NewWorkflowTypeRepo(r.Db, r.ctx).GetByOnlyName(onlyName)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	}
+
+	// 发起时使用当前生效版本的表单结构
+	formSchema := one.FormSchema
+	if one.ActiveVersion > 0 {
+		v := make(versionCache).get(r, one.ID, one.ActiveVersion)
+		if v != nil && v.TypeData != nil {
+			formSchema = v.TypeData.FormSchema
+		}
+	}
+
+	return workflow.ParseFormSchema(formSchema)
 }
 
 // TypeOptions 获取Label+Value格式的工作流类型列表
@@ -271,14 +736,16 @@ func (r *WorkflowService) NodeAdd(post dto.WorkflowNodeDto) (*repo.WorkflowNode,
 		post.Node = 1
 	}
 
+	// 校验节点设置
+	if err := r.checkNode(post); err != nil {
+		return nil, err
+	}
+
 	// 创建新对象
 	saveData := &repo.WorkflowNode{
-		TypeId:      typeData.ID,
-		Node:        post.Node,
-		Name:        post.Name,
-		Action:      post.Action,
-		ActionValue: post.ActionValue,
+		TypeId: typeData.ID,
 	}
+	r.fillNode(saveData, post)
 	createErr := workflowNodeRepo.Create(saveData)
 	return nil, exception.ErrorHandle(createErr, response.WorkflowNodeCreateFail)
 }
@@ -297,17 +764,85 @@ func (r *WorkflowService) NodeUpdate(post dto.WorkflowNodeDto) (*repo.WorkflowNo
 		post.Node = nodeData.Node
 	}
 
+	// 校验节点设置
+	if err := r.checkNode(post); err != nil {
+		return nil, err
+	}
+
 	// 修改数据
 	// 不允许修改 TypeId
-	nodeData.Name = post.Name
-	nodeData.Node = post.Node
-	nodeData.Action = post.Action
-	nodeData.ActionValue = post.ActionValue
+	r.fillNode(nodeData, post)
 
 	saveErr := workflowNodeRepo.Save(nodeData)
 	return nil, exception.ErrorHandle(saveErr, response.WorkflowNodeUpdateFail)
 }
 
+// checkNode 校验节点设置
+func (r *WorkflowService) checkNode(post dto.WorkflowNodeDto) error {
+	// 校验条件分支
+	if _, err := workflow.ParseBranches(post.Branches); err != nil {
+		return err
+	}
+	// 校验节点动作配置
+	if err := workflow.CheckActionValue(post.Action, post.ActionValue); err != nil {
+		return err
+	}
+	// 校验超时设置
+	if err := workflow.CheckTimeoutSetting(post.Timeout, post.TimeoutAction, post.FallbackUsers); err != nil {
+		return err
+	}
+	// 校验会签设置
+	if err := workflow.CheckApproveMode(post.ApproveMode, post.ApproveCount, post.ApprovePercent); err != nil {
+		return err
+	}
+	// 校验并行设置
+	if err := workflow.CheckParallel(post.Fork, post.JoinMode); err != nil {
+		return err
+	}
+	// 校验抄送设置
+	return workflow.CheckCcSetting(post.CcUsers, post.CcAction, post.CcActionValue)
+}
+
+// fillNode 将表单数据填充到节点，不修改 TypeId
+func (r *WorkflowService) fillNode(node *repo.WorkflowNode, post dto.WorkflowNodeDto) {
+	node.Name = post.Name
+	node.Node = post.Node
+	node.Action = post.Action
+	node.ActionValue = post.ActionValue
+	node.Branches = post.Branches
+	node.Timeout = post.Timeout
+	node.TimeoutAction = post.TimeoutAction
+	node.FallbackUsers = post.FallbackUsers
+	node.ApproveMode = post.ApproveMode
+	node.ApproveCount = post.ApproveCount
+	node.ApprovePercent = post.ApprovePercent
+	node.Fork = post.Fork
+	node.JoinMode = post.JoinMode
+	node.AllowAddSigner = 0
+	if post.AllowAddSigner {
+		node.AllowAddSigner = 1
+	}
+	node.AllowTransfer = 0
+	if post.AllowTransfer {
+		node.AllowTransfer = 1
+	}
+	node.CcUsers = post.CcUsers
+	node.CcAction = post.CcAction
+	node.CcActionValue = post.CcActionValue
+	// 兼容旧的 Everyone 字段
+	if post.ApproveMode == workflow.ApproveModeAll {
+		node.Everyone = 1
+	} else if post.ApproveMode != "" {
+		node.Everyone = 0
+	}
+	// 驳回是否只计为反对票
+	if post.CountReject {
+		node.CountReject = 1
+	} else {
+		node.CountReject = 0
+	}
+}
+
 func (r *WorkflowService) NodeDelete(id uint) error {
 	workflowNodeRepo := data.NewWorkflowNodeRepo(r.Db, r.ctx)
 	_, err := workflowNodeRepo.Get(id)
@@ -365,6 +900,42 @@ func (r *WorkflowService) Actions() []dto.UniversalSimpleList[string] {
 	return s
 }
 
+// ApproveModes 节点会签模式列表
+func (r *WorkflowService) ApproveModes() []dto.UniversalSimpleList[string] {
+	s := make([]dto.UniversalSimpleList[string], 0, len(workflow.ApproveModeMap))
+	for k, v := range workflow.ApproveModeMap {
+		s = append(s, dto.UniversalSimpleList[string]{
+			Label: v,
+			Value: k,
+		})
+	}
+	return s
+}
+
+// JoinModes 节点汇聚方式列表
+func (r *WorkflowService) JoinModes() []dto.UniversalSimpleList[string] {
+	s := make([]dto.UniversalSimpleList[string], 0, len(workflow.JoinModeMap))
+	for k, v := range workflow.JoinModeMap {
+		s = append(s, dto.UniversalSimpleList[string]{
+			Label: v,
+			Value: k,
+		})
+	}
+	return s
+}
+
+// TimeoutActions 节点超时动作列表
+func (r *WorkflowService) TimeoutActions() []dto.UniversalSimpleList[string] {
+	s := make([]dto.UniversalSimpleList[string], 0, len(workflow.TimeoutActionMap))
+	for k, v := range workflow.TimeoutActionMap {
+		s = append(s, dto.UniversalSimpleList[string]{
+			Label: v,
+			Value: k,
+		})
+	}
+	return s
+}
+
 // StatusList 工作流状态列表
 // 适配Antd Pro表格格式
 func (r *WorkflowService) StatusList() map[string]map[string]string {
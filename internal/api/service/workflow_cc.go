@@ -0,0 +1,58 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg"
+	"VitaTaskGo/internal/pkg/auth"
+	"VitaTaskGo/internal/pkg/workflow"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"github.com/golang-module/carbon/v2"
+)
+
+// CcList 抄送给当前用户的工作流
+func (r *WorkflowService) CcList(query dto.WorkflowCcQueryDto) (*dto.PagedResult[repo.WorkflowCc], error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	query.UserId = user.ID
+
+	l, total, err := data.NewWorkflowCcRepo(r.Db, r.ctx).PageList(query)
+	if err != nil {
+		return pkg.PagedResult[repo.WorkflowCc](nil, 0, int64(query.Page)), exception.ErrorHandle(err, response.DbQueryError, "列表查询失败: ")
+	}
+
+	// 给状态赋值
+	for _, item := range l {
+		if item.Workflow != nil {
+			item.Workflow.StatusText = workflow.StatusName(item.Workflow.Status)
+		}
+	}
+
+	return pkg.PagedResult(l, total, int64(query.Page)), nil
+}
+
+// CcRead 将抄送标记为已读
+func (r *WorkflowService) CcRead(post dto.WorkflowCcReadDto) error {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return err
+	}
+
+	err = data.NewWorkflowCcRepo(r.Db, r.ctx).MarkRead(user.ID, post.Ids, carbon.Now().TimestampMilli())
+	return exception.ErrorHandle(err, response.DbExecuteError)
+}
+
+// CcUnread 当前用户未读的抄送数量
+func (r *WorkflowService) CcUnread() (int64, error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := data.NewWorkflowCcRepo(r.Db, r.ctx).UnreadCount(user.ID)
+	return total, exception.ErrorHandle(err, response.DbQueryError)
+}
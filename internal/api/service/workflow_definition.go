@@ -0,0 +1,287 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/workflow"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"gorm.io/gorm"
+	"strings"
+)
+
+const (
+	definitionFormatJson = "json"
+	definitionFormatYaml = "yaml"
+)
+
+// TypeExport 导出工作流类型及其所有节点
+func (r *WorkflowService) TypeExport(post dto.WorkflowDefinitionExportDto) (*dto.WorkflowDefinitionFile, error) {
+	typeData, err := data.NewWorkflowTypeRepo(r.Db, r.ctx).Get(post.ID)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	}
+
+	nodes, err := data.NewWorkflowNodeRepo(r.Db, r.ctx).GetTypeNodes(typeData.ID)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	definition := dto.WorkflowDefinition{
+		Version: workflow.Version,
+		Type: dto.WorkflowDefinitionType{
+			Name:              typeData.Name,
+			OnlyName:          typeData.OnlyName,
+			Illustrate:        typeData.Illustrate,
+			OrgId:             typeData.OrgId,
+			System:            typeData.System == 1,
+			FormSchema:        typeData.FormSchema,
+			SerialPrefix:      typeData.SerialPrefix,
+			SerialDateFormat:  typeData.SerialDateFormat,
+			SerialPadding:     typeData.SerialPadding,
+			SerialIndependent: typeData.SerialIndependent == 1,
+			TaskGateStatus:    typeData.TaskGateStatus,
+		},
+		Nodes: make([]dto.WorkflowDefinitionNode, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		definition.Nodes = append(definition.Nodes, dto.WorkflowDefinitionNode{
+			Node:           node.Node,
+			Name:           node.Name,
+			Action:         node.Action,
+			ActionValue:    node.ActionValue,
+			Branches:       node.Branches,
+			Timeout:        node.Timeout,
+			TimeoutAction:  node.TimeoutAction,
+			FallbackUsers:  node.FallbackUsers,
+			ApproveMode:    workflow.ApproveMode(&node),
+			ApproveCount:   node.ApproveCount,
+			ApprovePercent: node.ApprovePercent,
+			CountReject:    node.CountReject == 1,
+			Fork:           node.Fork,
+			JoinMode:       node.JoinMode,
+			AllowAddSigner: node.AllowAddSigner == 1,
+			AllowTransfer:  node.AllowTransfer == 1,
+			CcUsers:        node.CcUsers,
+			CcAction:       node.CcAction,
+			CcActionValue:  node.CcActionValue,
+		})
+	}
+
+	format := strings.ToLower(post.Format)
+	var content []byte
+	switch format {
+	case definitionFormatYaml:
+		content, err = yaml.Marshal(definition)
+	case "", definitionFormatJson:
+		format = definitionFormatJson
+		content, err = json.MarshalIndent(definition, "", "  ")
+	default:
+		return nil, exception.NewException(response.WorkflowDefinitionInvalid, "不支持的格式")
+	}
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.SystemFail)
+	}
+
+	return &dto.WorkflowDefinitionFile{
+		Format:   format,
+		Filename: typeData.OnlyName + "." + format,
+		Content:  string(content),
+	}, nil
+}
+
+// TypeImport 导入工作流定义
+// 按 OnlyName 匹配已有的工作流类型，节点按序号新增、更新或删除，重复导入结果相同
+func (r *WorkflowService) TypeImport(post dto.WorkflowDefinitionImportDto) (*repo.WorkflowType, error) {
+	definition, err := r.parseDefinition(post.Format, post.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	typeDto, nodeDtos, err := r.checkDefinition(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeData *repo.WorkflowType
+	err = r.Db.Transaction(func(tx *gorm.DB) error {
+		workflowTypeRepo := data.NewWorkflowTypeRepo(tx, r.ctx)
+		workflowNodeRepo := data.NewWorkflowNodeRepo(tx, r.ctx)
+
+		// 按 OnlyName 查找已有的类型
+		typeData, err = workflowTypeRepo.GetByOnlyName(typeDto.OnlyName)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return exception.ErrorHandle(err, response.DbQueryError)
+		}
+		if typeData == nil || typeData.ID <= 0 {
+			typeData = &repo.WorkflowType{OnlyName: typeDto.OnlyName}
+		}
+		// 文档未指定组织时保留已有类型的组织
+		if typeDto.OrgId <= 0 {
+			typeDto.OrgId = typeData.OrgId
+		}
+		r.fillType(typeData, typeDto)
+		if err := workflowTypeRepo.Save(typeData); err != nil {
+			return exception.ErrorHandle(err, response.WorkflowDefinitionImportFail)
+		}
+
+		// 已有的节点按序号索引
+		nodes, err := workflowNodeRepo.GetTypeNodes(typeData.ID)
+		if err != nil {
+			return exception.ErrorHandle(err, response.DbQueryError)
+		}
+		existing := make(map[int]repo.WorkflowNode, len(nodes))
+		for _, node := range nodes {
+			existing[node.Node] = node
+		}
+
+		for _, nodeDto := range nodeDtos {
+			node, ok := existing[nodeDto.Node]
+			if !ok {
+				node = repo.WorkflowNode{TypeId: typeData.ID}
+			}
+			delete(existing, nodeDto.Node)
+
+			r.fillNode(&node, nodeDto)
+			if err := workflowNodeRepo.Save(&node); err != nil {
+				return exception.ErrorHandle(err, response.WorkflowDefinitionImportFail)
+			}
+		}
+
+		// 删除文档中不存在的节点
+		for _, node := range existing {
+			if err := workflowNodeRepo.Delete(node.ID); err != nil {
+				return exception.ErrorHandle(err, response.WorkflowDefinitionImportFail)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Publish {
+		version, err := r.TypePublish(dto.WorkflowTypePublishDto{ID: typeData.ID, Remark: "导入定义"})
+		if err != nil {
+			return nil, err
+		}
+		typeData.ActiveVersion = version.Version
+	}
+
+	return typeData, nil
+}
+
+// parseDefinition 解析定义文档
+func (r *WorkflowService) parseDefinition(format, content string) (*dto.WorkflowDefinition, error) {
+	definition := &dto.WorkflowDefinition{}
+
+	var err error
+	switch strings.ToLower(format) {
+	case definitionFormatYaml:
+		err = yaml.Unmarshal([]byte(content), definition)
+	case "", definitionFormatJson:
+		err = json.Unmarshal([]byte(content), definition)
+	default:
+		return nil, exception.NewException(response.WorkflowDefinitionInvalid, "不支持的格式")
+	}
+	if err != nil {
+		return nil, exception.NewException(response.WorkflowDefinitionInvalid, "文档解析失败: "+err.Error())
+	}
+	return definition, nil
+}
+
+// checkDefinition 校验定义文档，并转换为类型与节点的表单数据
+func (r *WorkflowService) checkDefinition(definition *dto.WorkflowDefinition) (dto.WorkflowTypeDto, []dto.WorkflowNodeDto, error) {
+	t := definition.Type
+	typeDto := dto.WorkflowTypeDto{
+		Name:              t.Name,
+		OnlyName:          t.OnlyName,
+		Illustrate:        t.Illustrate,
+		OrgId:             t.OrgId,
+		System:            t.System,
+		FormSchema:        t.FormSchema,
+		SerialPrefix:      t.SerialPrefix,
+		SerialDateFormat:  t.SerialDateFormat,
+		SerialPadding:     t.SerialPadding,
+		SerialIndependent: t.SerialIndependent,
+		TaskGateStatus:    t.TaskGateStatus,
+	}
+	if len(typeDto.OnlyName) <= 0 {
+		return typeDto, nil, exception.NewException(response.WorkflowTypeOnlyNameEmpty)
+	}
+	if err := r.checkType(typeDto); err != nil {
+		return typeDto, nil, err
+	}
+	if len(definition.Nodes) <= 0 {
+		return typeDto, nil, exception.NewException(response.WorkflowDefinitionInvalid, "至少需要一个节点")
+	}
+
+	nodeNumbers := make(map[int]bool, len(definition.Nodes))
+	for _, n := range definition.Nodes {
+		if n.Node <= 0 {
+			return typeDto, nil, exception.NewException(response.WorkflowDefinitionInvalid, "节点序号必须大于0")
+		}
+		if nodeNumbers[n.Node] {
+			return typeDto, nil, exception.NewException(response.WorkflowDefinitionInvalid, fmt.Sprintf("节点序号[%d]重复", n.Node))
+		}
+		nodeNumbers[n.Node] = true
+	}
+
+	nodeDtos := make([]dto.WorkflowNodeDto, 0, len(definition.Nodes))
+	for _, n := range definition.Nodes {
+		nodeDto := dto.WorkflowNodeDto{
+			Node:           n.Node,
+			Name:           n.Name,
+			Action:         n.Action,
+			ActionValue:    n.ActionValue,
+			Branches:       n.Branches,
+			Timeout:        n.Timeout,
+			TimeoutAction:  n.TimeoutAction,
+			FallbackUsers:  n.FallbackUsers,
+			ApproveMode:    n.ApproveMode,
+			ApproveCount:   n.ApproveCount,
+			ApprovePercent: n.ApprovePercent,
+			CountReject:    n.CountReject,
+			Fork:           n.Fork,
+			JoinMode:       n.JoinMode,
+			AllowAddSigner: n.AllowAddSigner,
+			AllowTransfer:  n.AllowTransfer,
+			CcUsers:        n.CcUsers,
+			CcAction:       n.CcAction,
+			CcActionValue:  n.CcActionValue,
+		}
+		if err := r.checkNode(nodeDto); err != nil {
+			return typeDto, nil, err
+		}
+
+		// 分支指向的节点必须存在
+		branches, _ := workflow.ParseBranches(n.Branches)
+		for _, branch := range branches {
+			if branch.Node > 0 && !nodeNumbers[branch.Node] {
+				return typeDto, nil, exception.NewException(
+					response.WorkflowDefinitionInvalid,
+					fmt.Sprintf("节点[%d]的分支指向了不存在的节点[%d]", n.Node, branch.Node),
+				)
+			}
+		}
+		// 并行分支指向的节点必须存在
+		forks, _ := workflow.ParseFork(n.Fork)
+		for _, fork := range forks {
+			if !nodeNumbers[fork] {
+				return typeDto, nil, exception.NewException(
+					response.WorkflowDefinitionInvalid,
+					fmt.Sprintf("节点[%d]的并行分支指向了不存在的节点[%d]", n.Node, fork),
+				)
+			}
+		}
+		nodeDtos = append(nodeDtos, nodeDto)
+	}
+
+	return typeDto, nodeDtos, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/api/model/dto"
+	"VitaTaskGo/internal/pkg/auth"
+	"VitaTaskGo/internal/pkg/workflow"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// TypePublish 发布工作流定义
+// 保存当前类型与所有节点的快照作为新版本，之后发起的工作流使用该版本，运行中的工作流不受影响
+func (r *WorkflowService) TypePublish(post dto.WorkflowTypePublishDto) (*repo.WorkflowTypeVersion, error) {
+	user, err := auth.CurrUser(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var version *repo.WorkflowTypeVersion
+	err = r.Db.Transaction(func(tx *gorm.DB) error {
+		workflowTypeRepo := data.NewWorkflowTypeRepo(tx, r.ctx)
+		workflowTypeVersionRepo := data.NewWorkflowTypeVersionRepo(tx, r.ctx)
+
+		typeData, err := workflowTypeRepo.Get(post.ID)
+		if err != nil {
+			return db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+		}
+
+		nodes, err := data.NewWorkflowNodeRepo(tx, r.ctx).GetTypeNodes(typeData.ID)
+		if err != nil {
+			return exception.ErrorHandle(err, response.DbQueryError)
+		}
+		if len(nodes) <= 0 {
+			return exception.NewException(response.WorkflowEngineNoFirstNodeSet)
+		}
+
+		latest, err := workflowTypeVersionRepo.GetLatestVersion(typeData.ID)
+		if err != nil {
+			return exception.ErrorHandle(err, response.DbQueryError)
+		}
+
+		typeData.ActiveVersion = latest + 1
+		version = &repo.WorkflowTypeVersion{
+			TypeId:    typeData.ID,
+			Version:   typeData.ActiveVersion,
+			Publisher: user.ID,
+			Nickname:  user.UserNickname,
+			Remark:    post.Remark,
+		}
+		if err := version.SetSnapshot(typeData, nodes); err != nil {
+			return exception.ErrorHandle(err, response.WorkflowTypePublishFail)
+		}
+		if err := workflowTypeVersionRepo.Create(version); err != nil {
+			return exception.ErrorHandle(err, response.WorkflowTypePublishFail)
+		}
+
+		err = workflowTypeRepo.UpdateField(typeData.ID, "active_version", typeData.ActiveVersion)
+		return exception.ErrorHandle(err, response.WorkflowTypePublishFail)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// TypeVersions 工作流类型的所有版本
+func (r *WorkflowService) TypeVersions(typeId uint) ([]repo.WorkflowTypeVersion, error) {
+	list, err := data.NewWorkflowTypeVersionRepo(r.Db, r.ctx).GetVersions(typeId)
+	return list, exception.ErrorHandle(err, response.DbQueryError)
+}
+
+// TypeVersionDetail 工作流类型指定版本的快照
+func (r *WorkflowService) TypeVersionDetail(post dto.WorkflowTypeVersionDto) (*repo.WorkflowTypeVersion, error) {
+	one, err := data.NewWorkflowTypeVersionRepo(r.Db, r.ctx).GetVersion(post.ID, post.Version)
+	return one, db.FirstQueryErrorHandle(err, response.WorkflowTypeVersionNotExist)
+}
+
+// fillOutdatedRunning 统计还在使用旧版本运行的工作流数量
+func (r *WorkflowService) fillOutdatedRunning(list []repo.WorkflowType) error {
+	workflowRepo := data.NewWorkflowRepo(r.Db, r.ctx)
+	status := []int{workflow.StatusRunning, workflow.StatusOverrule, workflow.StatusWithdrawn}
+	for i, item := range list {
+		if item.ActiveVersion <= 0 {
+			continue
+		}
+		total, err := workflowRepo.CountOutdated(item.ID, item.ActiveVersion, status)
+		if err != nil {
+			return err
+		}
+		list[i].OutdatedRunning = total
+	}
+	return nil
+}
+
+// versionCache 按类型与版本缓存查询过的版本快照
+type versionCache map[string]*repo.WorkflowTypeVersion
+
+// get 获取版本快照，查询失败时返回nil
+func (c versionCache) get(r *WorkflowService, typeId uint, version int) *repo.WorkflowTypeVersion {
+	key := strconv.FormatUint(uint64(typeId), 10) + ":" + strconv.Itoa(version)
+	if v, ok := c[key]; ok {
+		return v
+	}
+
+	v, err := data.NewWorkflowTypeVersionRepo(r.Db, r.ctx).GetVersion(typeId, version)
+	if err != nil {
+		v = nil
+	}
+	c[key] = v
+	return v
+}
+
+// workflowNode 获取工作流的指定节点，已固定版本的工作流从版本快照中读取
+func (r *WorkflowService) workflowNode(cache versionCache, item repo.Workflow, node int) (*repo.WorkflowNode, error) {
+	if item.Version <= 0 {
+		return data.NewWorkflowNodeRepo(r.Db, r.ctx).GetAppointNode(item.TypeId, node)
+	}
+
+	v := cache.get(r, item.TypeId, item.Version)
+	if v == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	for _, n := range v.Nodes {
+		if n.Node == node {
+			return &n, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
@@ -6,6 +6,7 @@ import (
 	"VitaTaskGo/pkg/db"
 	"flag"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"os"
 	"strings"
 )
@@ -25,12 +26,84 @@ func AutoMigrate(f *flag.FlagSet) bool {
 	if len(strings.TrimSpace(test)) > 0 {
 		logrus.Debugln("命令行测试", test)
 	}
+	// 创建编号唯一索引前处理已有的重复编号
+	if err := dedupeWorkflowSerials(); err != nil {
+		logrus.Errorln(err)
+		return false
+	}
 	// 执行数据迁移
 	err := db.Db.Set("gorm:table_options", "ENGINE=InnoDB").
-		AutoMigrate(&repo.Dialog{}, &repo.DialogMsg{}, &repo.DialogUser{})
+		AutoMigrate(
+			&repo.Dialog{}, &repo.DialogMsg{}, &repo.DialogUser{},
+			&repo.WorkflowType{}, &repo.WorkflowNode{}, &repo.Workflow{}, &repo.WorkflowOperator{}, &repo.WorkflowData{}, &repo.WorkflowLog{}, &repo.WorkflowSequence{}, &repo.WorkflowDelegation{}, &repo.WorkflowTypeVersion{}, &repo.WorkflowToken{}, &repo.WorkflowCc{},
+			&repo.TaskDependency{},
+			&repo.TaskRecurrence{},
+			&repo.TaskField{},
+			&repo.TaskFieldValue{},
+			&repo.TaskStatus{},
+			&repo.TaskComment{}, &repo.TaskCommentHistory{},
+		)
 	if err != nil {
 		logrus.Errorln(err)
 		return false
 	}
+	// 任务与任务日志的基础表由 vita_task.sql 创建，这里只补充后续新增的字段
+	columns := []struct {
+		model interface{}
+		field string
+		index string // 字段的索引名称，为空时不创建
+	}{
+		{&repo.Task{}, "ParentId", "parent_id"},
+		{&repo.Task{}, "RecurrenceId", ""},
+		{&repo.Task{}, "StatusId", ""},
+		{&repo.TaskLog{}, "Changes", ""},
+	}
+	migrator := db.Db.Migrator()
+	for _, column := range columns {
+		if !migrator.HasColumn(column.model, column.field) {
+			if err := migrator.AddColumn(column.model, column.field); err != nil {
+				logrus.Errorln(err)
+				return false
+			}
+		}
+		if len(column.index) > 0 && !migrator.HasIndex(column.model, column.index) {
+			if err := migrator.CreateIndex(column.model, column.index); err != nil {
+				logrus.Errorln(err)
+				return false
+			}
+		}
+	}
 	return false
 }
+
+// dedupeWorkflowSerials 处理同一类型内重复的工作流编号
+// 保留最早的一条，其余的编号后追加工作流ID，索引已存在时跳过
+func dedupeWorkflowSerials() error {
+	migrator := db.Db.Migrator()
+	if !migrator.HasTable(&repo.Workflow{}) || migrator.HasIndex(&repo.Workflow{}, "serials") {
+		return nil
+	}
+
+	var duplicates []struct {
+		TypeId  uint
+		Serials string
+		MinId   uint
+	}
+	err := db.Db.Unscoped().Model(&repo.Workflow{}).
+		Select("type_id, serials, MIN(id) AS min_id").
+		Group("type_id, serials").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error
+	if err != nil {
+		return err
+	}
+	for _, duplicate := range duplicates {
+		err = db.Db.Unscoped().Model(&repo.Workflow{}).
+			Where("type_id = ? AND serials = ? AND id <> ?", duplicate.TypeId, duplicate.Serials, duplicate.MinId).
+			UpdateColumn("serials", gorm.Expr("CONCAT(serials, '-', id)")).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
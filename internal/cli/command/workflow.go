@@ -0,0 +1,76 @@
+package command
+
+import (
+	"VitaTaskGo/internal/api/data"
+	// 注册工作流钩子需要的业务处理
+	_ "VitaTaskGo/internal/api/service"
+	"VitaTaskGo/internal/cli"
+	"VitaTaskGo/internal/pkg/workflow"
+	"VitaTaskGo/pkg/db"
+	"context"
+	"flag"
+	"github.com/sirupsen/logrus"
+	"os"
+)
+
+func init() {
+	cli.Register("workflow-approve", WorkflowApprove)
+	cli.Register("workflow-timeout", WorkflowTimeout)
+}
+
+// WorkflowApprove 以指定用户的身份审批工作流
+// 例如: vita-cli workflow-approve -id 1 -user 1 -action next -comment 同意
+func WorkflowApprove(f *flag.FlagSet) bool {
+	var (
+		id      uint
+		userId  uint64
+		node    int
+		action  string
+		comment string
+	)
+	f.UintVar(&id, "id", 0, "工作流ID")
+	f.Uint64Var(&userId, "user", 0, "操作人ID")
+	f.IntVar(&node, "node", 0, "并行审批时要处理的分支节点")
+	f.StringVar(&action, "action", workflow.LogActionNext, "动作 next-同意 overrule-驳回 cancel-作废 withdraw-撤回")
+	f.StringVar(&comment, "comment", "", "审批意见")
+	// 忽略错误
+	_ = f.Parse(os.Args[2:])
+
+	workflow.Init()
+
+	user, err := data.NewUserRepo(db.Db, nil).GetUser(userId)
+	if err != nil {
+		logrus.Errorln("操作人不存在:", err)
+		return false
+	}
+
+	engine, err := workflow.Open(db.Db, context.Background(), user, id)
+	if err != nil {
+		logrus.Errorln("工作流打开失败:", err)
+		return false
+	}
+
+	formData := map[string]interface{}{
+		"action":  action,
+		"comment": comment,
+	}
+	if node > 0 {
+		formData["node"] = node
+	}
+	engine.SetFormData(formData)
+	if err := engine.ExamineApprove(); err != nil {
+		logrus.Errorln("工作流审批失败:", err)
+		return false
+	}
+	return true
+}
+
+// WorkflowTimeout 执行一次工作流审批超时扫描
+func WorkflowTimeout(f *flag.FlagSet) bool {
+	// 忽略错误
+	_ = f.Parse(os.Args[2:])
+
+	workflow.Init()
+	workflow.CheckTimeout(db.Db)
+	return true
+}
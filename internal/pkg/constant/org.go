@@ -0,0 +1,6 @@
+package constant
+
+const (
+	OrgMember  = iota // 组织成员
+	OrgManager        // 组织负责人
+)
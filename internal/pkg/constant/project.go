@@ -26,6 +26,14 @@ const (
 	TaskStatusArchived
 )
 
+// 任务状态分类，任务的 status 字段保存分类，项目自定义的状态保存在 status_id 字段
+// 没有自定义状态的项目直接使用分类作为状态
+const (
+	TaskCategoryOpen     = TaskStatusProcessing // 未完成
+	TaskCategoryDone     = TaskStatusCompleted  // 已完成
+	TaskCategoryArchived = TaskStatusArchived   // 已归档
+)
+
 const (
 	TaskOperatorCreate             = "create"
 	TaskOperatorUpdate             = "update"
@@ -34,6 +42,24 @@ const (
 	TaskOperatorRemoveLeader       = "remove_leader"
 	TaskOperatorChangeLeader       = "change_leader"
 	TaskOperatorChangeCollaborator = "change_collaborator"
+	TaskOperatorDependency         = "dependency"
+	TaskOperatorRecurrence         = "recurrence"
+	TaskOperatorField              = "field"
+)
+
+const (
+	TaskFieldText        = "text"
+	TaskFieldNumber      = "number"
+	TaskFieldDate        = "date"
+	TaskFieldSelect      = "select"
+	TaskFieldMultiSelect = "multi_select"
+	TaskFieldUser        = "user"
+)
+
+// 任务评论历史的操作类型
+const (
+	TaskCommentEdit   = "edit"
+	TaskCommentDelete = "delete"
 )
 
 var projectRole = map[int]string{
@@ -57,6 +83,21 @@ var taskStatus = map[int]string{
 	TaskStatusArchived:   "已归档",
 }
 
+var taskCategory = map[int]string{
+	TaskCategoryOpen:     "未完成",
+	TaskCategoryDone:     "已完成",
+	TaskCategoryArchived: "已归档",
+}
+
+var taskFieldType = map[string]string{
+	TaskFieldText:        "文本",
+	TaskFieldNumber:      "数字",
+	TaskFieldDate:        "日期",
+	TaskFieldSelect:      "单选",
+	TaskFieldMultiSelect: "多选",
+	TaskFieldUser:        "成员",
+}
+
 func GetProjectRoles() map[int]string {
 	return projectRole
 }
@@ -74,6 +115,19 @@ func GetTaskRoles() map[int]string {
 	return taskRole
 }
 
+func GetTaskCategories() map[int]string {
+	return taskCategory
+}
+
+// GetTaskFinishedCategories 视为已完成的分类，包括已归档
+func GetTaskFinishedCategories() []int {
+	return []int{TaskCategoryDone, TaskCategoryArchived}
+}
+
+func GetTaskFieldTypes() map[string]string {
+	return taskFieldType
+}
+
 func GetTaskStatus() map[int]string {
 	return taskStatus
 }
@@ -87,5 +141,8 @@ func GetTaskLogOperatorMaps() map[string]string {
 		TaskOperatorRemoveLeader:       "移除负责人",
 		TaskOperatorChangeLeader:       "变更负责人",
 		TaskOperatorChangeCollaborator: "变更协作人",
+		TaskOperatorDependency:         "变更依赖",
+		TaskOperatorRecurrence:         "重复任务",
+		TaskOperatorField:              "修改自定义字段",
 	}
 }
@@ -0,0 +1,190 @@
+package recurrence
+
+import (
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// FreqMap 重复频率名称
+var FreqMap = map[string]string{
+	FreqDaily:   "每天",
+	FreqWeekly:  "每周",
+	FreqMonthly: "每月",
+}
+
+// maxSearchDays 查找下一次重复时最多向后查找的天数
+const maxSearchDays = 366 * 5
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Rule 重复规则，支持 RRULE 的子集
+// 例如 FREQ=DAILY;INTERVAL=2、FREQ=WEEKLY;BYDAY=MO,FR、FREQ=MONTHLY;BYMONTHDAY=1,-1
+type Rule struct {
+	Freq     string
+	Interval int
+	// 每周的哪几天，为空时使用开始日期的星期
+	ByDay []time.Weekday
+	// 每月的哪几天，负数表示倒数第几天，为空时使用开始日期的日
+	ByMonthDay []int
+}
+
+// Parse 解析重复规则
+func Parse(s string) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if len(s) <= 0 {
+		return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "重复规则不能为空")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		if len(strings.TrimSpace(part)) <= 0 {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, fmt.Sprintf("无法解析[%s]", part))
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(kv[0])), strings.ToUpper(strings.TrimSpace(kv[1]))
+
+		switch key {
+		case "FREQ":
+			if _, ok := FreqMap[value]; !ok {
+				return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "不支持的重复频率")
+			}
+			rule.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval <= 0 {
+				return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "重复间隔必须大于0")
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.TrimSpace(day)]
+				if !ok {
+					return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, fmt.Sprintf("无法解析星期[%s]", day))
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				d, err := strconv.Atoi(strings.TrimSpace(day))
+				if err != nil || d == 0 || d > 31 || d < -31 {
+					return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, fmt.Sprintf("无法解析日期[%s]", day))
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, d)
+			}
+		default:
+			return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, fmt.Sprintf("不支持的规则[%s]", key))
+		}
+	}
+
+	if len(rule.Freq) <= 0 {
+		return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "缺少重复频率")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly {
+		return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "BYDAY 只能用于每周重复")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != FreqMonthly {
+		return nil, exception.NewException(response.TaskRecurrenceRuleInvalid, "BYMONTHDAY 只能用于每月重复")
+	}
+	return rule, nil
+}
+
+// Next 获取 after 之后的下一次重复日期，start 为规则的开始日期
+// 返回当天零点，找不到时返回零值
+func (r *Rule) Next(start, after time.Time) time.Time {
+	start = dateOf(start)
+	d := dateOf(after).AddDate(0, 0, 1)
+	if d.Before(start) {
+		d = start
+	}
+
+	for i := 0; i < maxSearchDays; i++ {
+		if r.match(start, d) {
+			return d
+		}
+		d = d.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// match 日期是否符合规则
+func (r *Rule) match(start, d time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		return daysBetween(start, d)%r.Interval == 0
+	case FreqWeekly:
+		// 间隔按开始日期所在的周计算
+		weeks := daysBetween(weekStart(start), weekStart(d)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) <= 0 {
+			return d.Weekday() == start.Weekday()
+		}
+		for _, weekday := range r.ByDay {
+			if d.Weekday() == weekday {
+				return true
+			}
+		}
+		return false
+	case FreqMonthly:
+		months := (d.Year()-start.Year())*12 + int(d.Month()) - int(start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		days := []int{start.Day()}
+		if len(r.ByMonthDay) > 0 {
+			days = r.ByMonthDay
+		}
+		// 当月最后一天
+		last := d.AddDate(0, 1, -d.Day()).Day()
+		for _, day := range days {
+			if day < 0 {
+				day = last + day + 1
+			}
+			if d.Day() == day {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// dateOf 当天零点
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekStart 所在周的周一
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return dateOf(t).AddDate(0, 0, -offset)
+}
+
+// daysBetween 两个日期相差的天数，按日历日计算
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
@@ -0,0 +1,80 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{"FREQ=DAILY", false},
+		{"RRULE:freq=weekly;interval=2;byday=mo,fr", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", false},
+		{"", true},
+		{"FREQ", true},
+		{"INTERVAL=2", true},
+		{"FREQ=YEARLY", true},
+		{"FREQ=DAILY;INTERVAL=0", true},
+		{"FREQ=DAILY;BYDAY=MO", true},
+		{"FREQ=WEEKLY;BYDAY=XX", true},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=0", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", true},
+		{"FREQ=MONTHLY;COUNT=3", true},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.rule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  time.Time
+	}{
+		{"每隔一天", "FREQ=DAILY;INTERVAL=2", date(2024, 1, 1), date(2024, 1, 1), date(2024, 1, 3)},
+		{"每隔一天跨月", "FREQ=DAILY;INTERVAL=2", date(2024, 1, 1), date(2024, 1, 31), date(2024, 2, 2)},
+		{"默认使用开始日期的星期", "FREQ=WEEKLY", date(2024, 1, 3), date(2024, 1, 3), date(2024, 1, 10)},
+		{"隔周的同一周内", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", date(2024, 1, 3), date(2024, 1, 3), date(2024, 1, 5)},
+		{"隔周跳过下一周", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", date(2024, 1, 3), date(2024, 1, 5), date(2024, 1, 15)},
+		{"隔周的周日属于周一开始的周", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", date(2024, 1, 1), date(2024, 1, 1), date(2024, 1, 7)},
+		{"隔周的周日跨周", "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU", date(2024, 1, 1), date(2024, 1, 7), date(2024, 1, 21)},
+		{"隔周跨年", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", date(2024, 12, 24), date(2024, 12, 24), date(2025, 1, 7)},
+		{"每月最后一天闰年", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2024, 1, 1), date(2024, 1, 31), date(2024, 2, 29)},
+		{"每月最后一天平年", "FREQ=MONTHLY;BYMONTHDAY=-1", date(2023, 1, 1), date(2023, 1, 31), date(2023, 2, 28)},
+		{"每月倒数第二天", "FREQ=MONTHLY;BYMONTHDAY=-2", date(2024, 4, 1), date(2024, 4, 1), date(2024, 4, 29)},
+		{"31日跳过短月", "FREQ=MONTHLY;BYMONTHDAY=31", date(2024, 1, 1), date(2024, 1, 31), date(2024, 3, 31)},
+		{"31日跳过四月", "FREQ=MONTHLY;BYMONTHDAY=31", date(2024, 1, 1), date(2024, 3, 31), date(2024, 5, 31)},
+		{"默认使用开始日期的日", "FREQ=MONTHLY", date(2024, 1, 31), date(2024, 1, 31), date(2024, 3, 31)},
+		{"隔月的31日", "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=31", date(2024, 1, 1), date(2024, 3, 31), date(2024, 5, 31)},
+		{"每月多天", "FREQ=MONTHLY;BYMONTHDAY=1,-1", date(2024, 2, 1), date(2024, 2, 1), date(2024, 2, 29)},
+		{"开始日期晚于after", "FREQ=DAILY", date(2024, 3, 10), date(2024, 1, 1), date(2024, 3, 10)},
+		{"开始日期晚于after的每周", "FREQ=WEEKLY;BYDAY=MO", date(2024, 1, 3), date(2023, 12, 1), date(2024, 1, 8)},
+		{"开始日期晚于after的每月", "FREQ=MONTHLY;BYMONTHDAY=1", date(2024, 1, 15), date(2023, 12, 1), date(2024, 2, 1)},
+		{"after包含时间", "FREQ=DAILY", date(2024, 1, 1), date(2024, 1, 1).Add(15 * time.Hour), date(2024, 1, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+			}
+			if got := rule.Next(tt.start, tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next() = %s, want %s", got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"VitaTaskGo/internal/repo"
 	"VitaTaskGo/pkg/exception"
 	"VitaTaskGo/pkg/response"
+	"strings"
 )
 
 type NodeAction interface {
 	ActionName() string
-	Handle(engine *Engine) ([]repo.User, error)
+	// Handle 获取节点的操作人，node.ActionValue 为动作的配置
+	Handle(engine *Engine, node *repo.WorkflowNode) ([]repo.User, error)
+}
+
+// NodeActionChecker 需要校验 ActionValue 的节点动作可以实现该接口
+type NodeActionChecker interface {
+	CheckValue(value string) error
 }
 
 var ActionPool = make(map[string]NodeAction)
@@ -30,6 +37,29 @@ func GetAllActionName() map[string]string {
 	return kv
 }
 
+// CheckActionValue 校验节点动作的配置
+// ActionValue 为json数组时表示直接指定用户
+func CheckActionValue(name, value string) error {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if _, err := ParseUserIds(value); err != nil {
+			return exception.NewException(response.WorkflowNodeActionValueInvalid, "指定用户格式错误")
+		}
+		return nil
+	}
+	if len(name) <= 0 {
+		return nil
+	}
+
+	na, err := GetAction(name)
+	if err != nil {
+		return err
+	}
+	if checker, ok := na.(NodeActionChecker); ok {
+		return checker.CheckValue(value)
+	}
+	return nil
+}
+
 func GetAction(name string) (NodeAction, error) {
 	na, ok := ActionPool[name]
 	if !ok {
@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"fmt"
+	"strconv"
+)
+
+// CheckCcSetting 校验节点的抄送设置
+// CcUsers 为json数组格式的用户ID，CcAction 为已注册的节点动作，两者可以同时设置
+func CheckCcSetting(users, action, actionValue string) error {
+	if _, err := ParseUserIds(users); err != nil {
+		return exception.NewException(response.WorkflowNodeCcInvalid, "抄送人格式错误")
+	}
+	if len(action) <= 0 {
+		return nil
+	}
+	if _, err := GetAction(action); err != nil {
+		return exception.NewException(response.WorkflowNodeCcInvalid, "抄送动作不存在")
+	}
+	if err := CheckActionValue(action, actionValue); err != nil {
+		return exception.NewException(response.WorkflowNodeCcInvalid, response.Error(err).Message)
+	}
+	return nil
+}
+
+// ccUsers 获取节点的抄送人，已去重
+func (engine *Engine) ccUsers(node *repo.WorkflowNode) ([]repo.User, error) {
+	users := make([]repo.User, 0)
+	exists := make(map[uint64]bool)
+	add := func(list []repo.User) {
+		for _, u := range list {
+			if u.ID > 0 && !exists[u.ID] {
+				exists[u.ID] = true
+				users = append(users, u)
+			}
+		}
+	}
+
+	ids, err := ParseUserIds(node.CcUsers)
+	if err != nil {
+		return nil, exception.NewException(response.WorkflowNodeCcInvalid, "抄送人格式错误")
+	}
+	if len(ids) > 0 {
+		list, err := data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers(ids)
+		if err != nil {
+			return nil, exception.ErrorHandle(err, response.DbQueryError)
+		}
+		add(list)
+	}
+
+	if len(node.CcAction) > 0 {
+		// 使用节点副本，按抄送动作的配置获取用户
+		ccNode := *node
+		ccNode.Action = node.CcAction
+		ccNode.ActionValue = node.CcActionValue
+		list, err := engine.nodeUsers(&ccNode)
+		if err != nil {
+			return nil, err
+		}
+		add(list)
+	}
+	return users, nil
+}
+
+// carbonCopy 到达节点时生成抄送记录，返回需要通知的用户ID
+// 需要在事务中调用，通知在事务提交后发送
+func (engine *Engine) carbonCopy(node *repo.WorkflowNode) ([]string, error) {
+	if node == nil || (len(node.CcUsers) <= 0 && len(node.CcAction) <= 0) {
+		return nil, nil
+	}
+
+	users, err := engine.ccUsers(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) <= 0 {
+		return nil, nil
+	}
+
+	list := make([]repo.WorkflowCc, 0, len(users))
+	notifyUsers := make([]string, 0, len(users))
+	for _, u := range users {
+		list = append(list, repo.WorkflowCc{
+			WorkflowId: engine.workflowId,
+			Node:       node.Node,
+			NodeName:   node.Name,
+			UserId:     u.ID,
+			Nickname:   u.UserNickname,
+		})
+		notifyUsers = append(notifyUsers, strconv.FormatUint(u.ID, 10))
+	}
+	if err := engine.Repo.workflowCcRepo.Save(list); err != nil {
+		return nil, exception.ErrorHandle(err, response.DbExecuteError)
+	}
+	return notifyUsers, nil
+}
+
+// notifyCc 发送抄送通知
+func (engine *Engine) notifyCc(users []string) {
+	engine.notify(users, LogActionCc, fmt.Sprintf("工作流[%s]抄送给您，请查阅", engine.workflow.Serials))
+}
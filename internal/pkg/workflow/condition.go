@@ -0,0 +1,253 @@
+package workflow
+
+import (
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NodeBranch 节点分支
+// Condition 为空表示默认分支，Node 小于等于0表示流转到该分支后结束工作流
+type NodeBranch struct {
+	Condition string `json:"condition"`
+	Node      int    `json:"node"`
+}
+
+// 比较运算符，长的必须放在前面，否则 >= 会被识别为 >
+var comparisonOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+type comparison struct {
+	field    string
+	operator string
+	value    interface{}
+}
+
+// Condition 条件表达式
+// 外层切片之间为 || 关系，内层切片之间为 && 关系
+type Condition [][]comparison
+
+// ParseBranches 解析节点分支配置
+// 配置为json数组字符串，例如 [{"condition":"amount > 5000","node":3},{"condition":"","node":4}]
+func ParseBranches(s string) ([]NodeBranch, error) {
+	branches := make([]NodeBranch, 0)
+	if len(strings.TrimSpace(s)) <= 0 {
+		return branches, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &branches); err != nil {
+		return nil, exception.NewException(response.WorkflowNodeBranchInvalid)
+	}
+
+	// 校验表达式
+	for _, branch := range branches {
+		if len(strings.TrimSpace(branch.Condition)) <= 0 {
+			continue
+		}
+		if _, err := ParseCondition(branch.Condition); err != nil {
+			return nil, err
+		}
+	}
+	return branches, nil
+}
+
+// ParseCondition 解析条件表达式
+// 支持 == != > >= < <= 运算符，使用 && 与 || 组合(&& 优先)，不支持括号
+// 引号内的运算符视为字面量的一部分
+// 例子: amount > 5000 && type == 'travel' || urgent == true
+func ParseCondition(expr string) (Condition, error) {
+	var condition Condition
+
+	for _, orPart := range splitOutsideQuotes(expr, "||") {
+		group := make([]comparison, 0)
+		for _, andPart := range splitOutsideQuotes(orPart, "&&") {
+			c, err := parseComparison(strings.TrimSpace(andPart))
+			if err != nil {
+				return nil, exception.NewException(response.WorkflowNodeConditionInvalid, fmt.Sprintf("条件表达式[%s]错误", expr))
+			}
+			group = append(group, c)
+		}
+		condition = append(condition, group)
+	}
+
+	return condition, nil
+}
+
+func parseComparison(s string) (comparison, error) {
+	i, operator := indexOutsideQuotes(s, comparisonOperators)
+	if i > 0 {
+		field := strings.TrimSpace(s[:i])
+		value := strings.TrimSpace(s[i+len(operator):])
+		if len(field) > 0 && len(value) > 0 && quoteClosed(value) {
+			return comparison{field: field, operator: operator, value: parseLiteral(value)}, nil
+		}
+	}
+
+	return comparison{}, fmt.Errorf("invalid comparison: %s", s)
+}
+
+// indexOutsideQuotes 查找第一个不在引号内的运算符，返回位置与运算符，找不到时位置为-1
+// 同一位置按 operators 的顺序匹配
+func indexOutsideQuotes(s string, operators []string) (int, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		if quote != 0 {
+			if s[i] == quote {
+				quote = 0
+			}
+			continue
+		}
+		if s[i] == '\'' || s[i] == '"' {
+			quote = s[i]
+			continue
+		}
+		for _, operator := range operators {
+			if strings.HasPrefix(s[i:], operator) {
+				return i, operator
+			}
+		}
+	}
+	return -1, ""
+}
+
+// splitOutsideQuotes 按分隔符拆分表达式，引号内的分隔符不拆分
+func splitOutsideQuotes(s, sep string) []string {
+	parts := make([]string, 0)
+	for {
+		i, _ := indexOutsideQuotes(s, []string{sep})
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+len(sep):]
+	}
+}
+
+// quoteClosed 引号只能包围整个字面量，以引号开头的必须在末尾闭合，且中间不能再出现同样的引号
+func quoteClosed(s string) bool {
+	if s[0] != '\'' && s[0] != '"' {
+		return !strings.ContainsAny(s, "'\"")
+	}
+	return strings.IndexByte(s[1:], s[0]) == len(s)-2
+}
+
+// 解析字面量，带引号的为字符串，其它的依次尝试布尔值、数字，都不是则视为字符串
+func parseLiteral(s string) interface{} {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// Evaluate 使用表单数据计算表达式结果
+func (c Condition) Evaluate(formData map[string]interface{}) bool {
+	for _, group := range c {
+		matched := true
+		for _, item := range group {
+			if !item.evaluate(formData) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparison) evaluate(formData map[string]interface{}) bool {
+	actual, ok := lookupField(formData, c.field)
+	if !ok {
+		// 字段不存在时只有 != 成立
+		return c.operator == "!="
+	}
+
+	// 两边都能转换为数字时按数字比较
+	if a, aOk := toFloat(actual); aOk {
+		if b, bOk := toFloat(c.value); bOk {
+			return compare(a, b, c.operator)
+		}
+	}
+
+	// 其它情况按字符串比较
+	return compare(fmt.Sprint(actual), fmt.Sprint(c.value), c.operator)
+}
+
+// lookupField 获取表单字段，支持用 . 访问嵌套字段
+func lookupField(formData map[string]interface{}, field string) (interface{}, bool) {
+	var current interface{} = formData
+	for _, key := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func compare[T float64 | string](a, b T, operator string) bool {
+	switch operator {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
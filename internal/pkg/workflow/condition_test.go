@@ -0,0 +1,52 @@
+package workflow
+
+import "testing"
+
+func TestParseConditionQuotedOperators(t *testing.T) {
+	condition, err := ParseCondition(`remark == 'a || b && c' && type != "x==y"`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(condition) != 1 || len(condition[0]) != 2 {
+		t.Fatalf("unexpected condition: %+v", condition)
+	}
+	if c := condition[0][0]; c.field != "remark" || c.operator != "==" || c.value != "a || b && c" {
+		t.Errorf("unexpected first comparison: %+v", c)
+	}
+	if c := condition[0][1]; c.field != "type" || c.operator != "!=" || c.value != "x==y" {
+		t.Errorf("unexpected second comparison: %+v", c)
+	}
+
+	if !condition.Evaluate(map[string]interface{}{"remark": "a || b && c", "type": "travel"}) {
+		t.Error("expected condition to match")
+	}
+	if condition.Evaluate(map[string]interface{}{"remark": "a", "type": "travel"}) {
+		t.Error("expected condition not to match")
+	}
+}
+
+func TestParseConditionOr(t *testing.T) {
+	condition, err := ParseCondition(`amount > 5000 && type == 'travel' || urgent == true`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if len(condition) != 2 || len(condition[0]) != 2 || len(condition[1]) != 1 {
+		t.Fatalf("unexpected condition: %+v", condition)
+	}
+	if !condition.Evaluate(map[string]interface{}{"amount": 100, "urgent": true}) {
+		t.Error("expected urgent to match")
+	}
+}
+
+func TestParseConditionInvalidQuotes(t *testing.T) {
+	for _, expr := range []string{
+		`remark == 'abc`,
+		`remark == 'a' b'`,
+		`remark == ab'c || type == 1`,
+		`'remark == 1'`,
+	} {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("expected %q to be invalid", expr)
+		}
+	}
+}
@@ -12,6 +12,9 @@ const (
 
 	// StatusOverrule 驳回
 	StatusOverrule
+
+	// StatusWithdrawn 已撤回
+	StatusWithdrawn
 )
 
 // StatusMap 状态Map
@@ -21,6 +24,17 @@ var StatusMap = map[string]int{
 	"completed": StatusCompleted,
 	"running":   StatusRunning,
 	"overrule":  StatusOverrule,
+	"withdrawn": StatusWithdrawn,
+}
+
+// StatusName 状态值对应的英文名称
+func StatusName(status int) string {
+	for name, value := range StatusMap {
+		if value == status {
+			return name
+		}
+	}
+	return ""
 }
 
 // StatusEnum 状态枚举，兼容Antd Pro
@@ -30,4 +44,72 @@ var StatusEnum = map[string]map[string]string{
 	"completed": {"text": "已完成", "status": "Success"},
 	"running":   {"text": "进行中", "status": "Processing"},
 	"overrule":  {"text": "驳回", "status": "Warning"},
+	"withdrawn": {"text": "已撤回", "status": "Default"},
+}
+
+const (
+	// LogActionInitiate 发起
+	LogActionInitiate = "initiate"
+	// LogActionNext 审批通过
+	LogActionNext = "next"
+	// LogActionOverrule 驳回
+	LogActionOverrule = "overrule"
+	// LogActionJump 驳回到指定节点
+	LogActionJump = "jump"
+	// LogActionReject 反对，未达到驳回条件
+	LogActionReject = "reject"
+	// LogActionCancel 作废
+	LogActionCancel = "cancel"
+	// LogActionAmend 修改附加数据
+	LogActionAmend = "amend"
+	// LogActionWithdraw 发起人撤回
+	LogActionWithdraw = "withdraw"
+	// LogActionRemind 超时提醒
+	LogActionRemind = "remind"
+	// LogActionEscalate 超时转交
+	LogActionEscalate = "escalate"
+	// LogActionAddSigner 加签
+	LogActionAddSigner = "add_signer"
+	// LogActionTransfer 转交
+	LogActionTransfer = "transfer"
+	// LogActionSignOpinion 前加签人提交意见
+	LogActionSignOpinion = "sign_opinion"
+	// LogActionCc 抄送，仅用于通知事件
+	LogActionCc = "cc"
+)
+
+// examineActions 审批时可以执行的动作
+var examineActions = []string{LogActionNext, LogActionOverrule, LogActionCancel, LogActionWithdraw, LogActionAddSigner, LogActionTransfer}
+
+// LogActionMap 日志操作类型名称
+var LogActionMap = map[string]string{
+	LogActionInitiate:    "发起",
+	LogActionNext:        "同意",
+	LogActionOverrule:    "驳回",
+	LogActionJump:        "驳回到指定节点",
+	LogActionReject:      "反对",
+	LogActionCancel:      "作废",
+	LogActionAmend:       "修改数据",
+	LogActionWithdraw:    "撤回",
+	LogActionRemind:      "超时提醒",
+	LogActionEscalate:    "超时转交",
+	LogActionAddSigner:   "加签",
+	LogActionTransfer:    "转交",
+	LogActionSignOpinion: "加签意见",
+}
+
+const (
+	// TimeoutActionRemind 超时提醒操作人
+	TimeoutActionRemind = "remind"
+	// TimeoutActionEscalate 超时转交给备用审批人
+	TimeoutActionEscalate = "escalate"
+	// TimeoutActionApprove 超时自动同意
+	TimeoutActionApprove = "approve"
+)
+
+// TimeoutActionMap 超时动作名称
+var TimeoutActionMap = map[string]string{
+	TimeoutActionRemind:   "提醒",
+	TimeoutActionEscalate: "转交给备用审批人",
+	TimeoutActionApprove:  "自动同意",
 }
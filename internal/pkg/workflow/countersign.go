@@ -0,0 +1,168 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+)
+
+const (
+	// ApproveModeAny 任意一人同意即通过
+	ApproveModeAny = "any"
+	// ApproveModeAll 所有人同意才通过
+	ApproveModeAll = "all"
+	// ApproveModeVote 达到指定人数或比例同意即通过
+	ApproveModeVote = "vote"
+)
+
+// ApproveModeMap 会签模式名称
+var ApproveModeMap = map[string]string{
+	ApproveModeAny:  "任意一人同意",
+	ApproveModeAll:  "所有人同意",
+	ApproveModeVote: "按人数或比例同意",
+}
+
+const (
+	// VoteApprove 同意
+	VoteApprove = "approve"
+	// VoteReject 反对
+	VoteReject = "reject"
+)
+
+const (
+	// decisionPending 节点还在等待其他人投票
+	decisionPending = iota
+	// decisionPass 节点通过
+	decisionPass
+	// decisionReject 节点被驳回
+	decisionReject
+)
+
+// CheckApproveMode 校验节点的会签设置
+func CheckApproveMode(mode string, count, percent int) error {
+	if mode == "" {
+		return nil
+	}
+	if _, ok := ApproveModeMap[mode]; !ok {
+		return exception.NewException(response.WorkflowNodeApproveModeInvalid, "会签模式不存在")
+	}
+	if count < 0 {
+		return exception.NewException(response.WorkflowNodeApproveModeInvalid, "通过人数不能小于0")
+	}
+	if percent < 0 || percent > 100 {
+		return exception.NewException(response.WorkflowNodeApproveModeInvalid, "通过比例必须在0到100之间")
+	}
+	if mode == ApproveModeVote && count <= 0 && percent <= 0 {
+		return exception.NewException(response.WorkflowNodeApproveModeInvalid, "按人数或比例同意时必须设置通过人数或比例")
+	}
+	return nil
+}
+
+// ApproveMode 节点的会签模式，未设置时兼容 Everyone 字段
+func ApproveMode(node *repo.WorkflowNode) string {
+	if node.ApproveMode != "" {
+		return node.ApproveMode
+	}
+	if node.Everyone == 1 {
+		return ApproveModeAll
+	}
+	return ApproveModeAny
+}
+
+// RequiredApprovals 节点通过所需的同意票数
+// 设置了通过人数时优先使用人数，否则按比例向上取整
+func RequiredApprovals(node *repo.WorkflowNode, total int) int {
+	required := 1
+	switch ApproveMode(node) {
+	case ApproveModeAll:
+		required = total
+	case ApproveModeVote:
+		if node.ApproveCount > 0 {
+			required = node.ApproveCount
+		} else if node.ApprovePercent > 0 {
+			required = (total*node.ApprovePercent + 99) / 100
+		}
+	}
+
+	if required > total {
+		required = total
+	}
+	if required < 1 {
+		required = 1
+	}
+	return required
+}
+
+// GetVoteProgress 统计节点操作人的投票情况
+func GetVoteProgress(node *repo.WorkflowNode, operators []repo.WorkflowOperator) *repo.WorkflowVoteProgress {
+	progress := &repo.WorkflowVoteProgress{
+		Mode: ApproveMode(node),
+	}
+	for _, operator := range operators {
+		// 加签人不参与计票
+		if operator.SignType != "" {
+			continue
+		}
+		progress.Total++
+		switch operatorVote(operator) {
+		case VoteApprove:
+			progress.Approved++
+		case VoteReject:
+			progress.Rejected++
+		}
+	}
+	progress.Required = RequiredApprovals(node, progress.Total)
+	return progress
+}
+
+// operatorVote 操作人的投票，兼容没有投票记录的已处理操作人
+func operatorVote(operator repo.WorkflowOperator) string {
+	if operator.Vote == "" && operator.Handled == 1 {
+		return VoteApprove
+	}
+	return operator.Vote
+}
+
+// countersign 计入当前用户的投票，返回投票后当前节点的结果
+func (engine *Engine) countersign(userId uint64, vote string) int {
+	// 没有操作人记录时直接按本次投票处理
+	if len(engine.operator) <= 0 {
+		if vote == VoteReject {
+			return decisionReject
+		}
+		return decisionPass
+	}
+
+	if vote == VoteReject {
+		// 任意一人驳回即驳回
+		if engine.nodeInfo.CountReject != 1 {
+			return decisionReject
+		}
+		// 后加签人驳回即驳回
+		if operator := engine.unhandledOperator(userId); operator != nil && operator.SignType == SignTypeAfter {
+			return decisionReject
+		}
+	}
+
+	operators := make([]repo.WorkflowOperator, len(engine.operator))
+	copy(operators, engine.operator)
+	for i := range operators {
+		if operators[i].UserId == userId {
+			operators[i].Vote = vote
+		}
+	}
+
+	progress := GetVoteProgress(engine.nodeInfo, operators)
+	if progress.Approved >= progress.Required {
+		// 后加签人全部同意后节点才通过
+		if engine.pendingSigners(SignTypeAfter, 0, userId) {
+			return decisionPending
+		}
+		return decisionPass
+	}
+	// 剩余的人全部同意也达不到通过票数
+	if progress.Total-progress.Rejected < progress.Required {
+		return decisionReject
+	}
+	return decisionPending
+}
@@ -0,0 +1,130 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/repo"
+	"testing"
+)
+
+func TestRequiredApprovals(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  repo.WorkflowNode
+		total int
+		want  int
+	}{
+		{"任意一人", repo.WorkflowNode{ApproveMode: ApproveModeAny}, 5, 1},
+		{"所有人", repo.WorkflowNode{ApproveMode: ApproveModeAll}, 5, 5},
+		{"所有人没有操作人", repo.WorkflowNode{ApproveMode: ApproveModeAll}, 0, 1},
+		{"兼容Everyone", repo.WorkflowNode{Everyone: 1}, 4, 4},
+		{"按人数", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApproveCount: 3}, 5, 3},
+		{"人数超过总数", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApproveCount: 7}, 5, 5},
+		{"人数优先于比例", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApproveCount: 1, ApprovePercent: 100}, 5, 1},
+		{"比例向上取整", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 50}, 5, 3},
+		{"比例整除", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 50}, 4, 2},
+		{"比例略高于三分之一", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 34}, 3, 2},
+		{"比例略低于三分之一", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 33}, 3, 1},
+		{"比例为100", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 100}, 3, 3},
+		{"比例很小时至少一票", repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 1}, 3, 1},
+		{"未设置人数与比例", repo.WorkflowNode{ApproveMode: ApproveModeVote}, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiredApprovals(&tt.node, tt.total); got != tt.want {
+				t.Errorf("RequiredApprovals() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountersign(t *testing.T) {
+	votePercent := repo.WorkflowNode{ApproveMode: ApproveModeVote, ApprovePercent: 50, CountReject: 1}
+	tests := []struct {
+		name      string
+		node      repo.WorkflowNode
+		operators []repo.WorkflowOperator
+		userId    uint64
+		vote      string
+		want      int
+	}{
+		{"没有操作人时同意", repo.WorkflowNode{}, nil, 1, VoteApprove, decisionPass},
+		{"没有操作人时反对", repo.WorkflowNode{}, nil, 1, VoteReject, decisionReject},
+		{
+			"任意一人同意", repo.WorkflowNode{ApproveMode: ApproveModeAny},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2}, {UserId: 3}},
+			1, VoteApprove, decisionPass,
+		},
+		{
+			"不计反对票时一人反对即驳回", repo.WorkflowNode{ApproveMode: ApproveModeAll},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2}},
+			1, VoteReject, decisionReject,
+		},
+		{
+			"所有人同意时等待其他人", repo.WorkflowNode{ApproveMode: ApproveModeAll},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2}, {UserId: 3}},
+			1, VoteApprove, decisionPending,
+		},
+		{
+			"所有人同意时最后一人同意", repo.WorkflowNode{ApproveMode: ApproveModeAll},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2, Handled: 1, Vote: VoteApprove}, {UserId: 3, Handled: 1, Vote: VoteApprove}},
+			1, VoteApprove, decisionPass,
+		},
+		{
+			"没有投票记录的已处理操作人视为同意", repo.WorkflowNode{ApproveMode: ApproveModeAll},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2, Handled: 1}},
+			1, VoteApprove, decisionPass,
+		},
+		{
+			"计反对票时仍可能通过", votePercent,
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2}, {UserId: 3}, {UserId: 4}},
+			1, VoteReject, decisionPending,
+		},
+		{
+			"剩余票数恰好达到通过票数", votePercent,
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2, Handled: 1, Vote: VoteReject}, {UserId: 3}, {UserId: 4}},
+			1, VoteReject, decisionPending,
+		},
+		{
+			"剩余票数达不到通过票数时提前驳回", votePercent,
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2, Handled: 1, Vote: VoteReject}, {UserId: 3, Handled: 1, Vote: VoteReject}, {UserId: 4}},
+			1, VoteReject, decisionReject,
+		},
+		{
+			"比例达到后通过", votePercent,
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2, Handled: 1, Vote: VoteApprove}, {UserId: 3}, {UserId: 4}},
+			1, VoteApprove, decisionPass,
+		},
+		{
+			"所有人同意时计反对票仍提前驳回", repo.WorkflowNode{ApproveMode: ApproveModeAll, CountReject: 1},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 2}, {UserId: 3}},
+			1, VoteReject, decisionReject,
+		},
+		{
+			"后加签人未处理时等待", repo.WorkflowNode{ApproveMode: ApproveModeAny},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 9, SignType: SignTypeAfter, SignBy: 1}},
+			1, VoteApprove, decisionPending,
+		},
+		{
+			"后加签人同意后通过", repo.WorkflowNode{ApproveMode: ApproveModeAny},
+			[]repo.WorkflowOperator{{UserId: 1, Handled: 1, Vote: VoteApprove}, {UserId: 9, SignType: SignTypeAfter, SignBy: 1}},
+			9, VoteApprove, decisionPass,
+		},
+		{
+			"计反对票时后加签人反对即驳回", repo.WorkflowNode{ApproveMode: ApproveModeAny, CountReject: 1},
+			[]repo.WorkflowOperator{{UserId: 1, Handled: 1, Vote: VoteApprove}, {UserId: 9, SignType: SignTypeAfter, SignBy: 1}},
+			9, VoteReject, decisionReject,
+		},
+		{
+			"加签人不参与计票", repo.WorkflowNode{ApproveMode: ApproveModeAll},
+			[]repo.WorkflowOperator{{UserId: 1}, {UserId: 8, SignType: SignTypeBefore, SignBy: 1, Handled: 1}},
+			1, VoteApprove, decisionPass,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{nodeInfo: &tt.node, operator: tt.operators}
+			if got := engine.countersign(tt.userId, tt.vote); got != tt.want {
+				t.Errorf("countersign() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,26 +2,33 @@ package workflow
 
 import (
 	"VitaTaskGo/internal/api/data"
-	"VitaTaskGo/internal/pkg/auth"
 	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/config"
 	"VitaTaskGo/pkg/db"
 	"VitaTaskGo/pkg/exception"
 	"VitaTaskGo/pkg/response"
+	"context"
+	"encoding/json"
 	"errors"
-	"github.com/duke-git/lancet/v2/random"
+	"fmt"
 	"github.com/duke-git/lancet/v2/slice"
 	"github.com/duke-git/lancet/v2/strutil"
-	"github.com/gin-gonic/gin"
 	"github.com/golang-module/carbon/v2"
 	"github.com/valyala/fastjson"
 	"gorm.io/gorm"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Engine struct {
 	Orm            *gorm.DB
 	TransactionOrm *gorm.DB // 事务Orm，事务结束后记得清除
-	ctx            *gin.Context
+	ctx            context.Context
+	// 操作人，由调用方指定，不依赖HTTP请求
+	actor *repo.User
+	// 由系统代替操作人处理，日志的操作人记录为系统
+	bySystem bool
 
 	typeId     uint
 	typeData   *repo.WorkflowType
@@ -29,11 +36,26 @@ type Engine struct {
 	workflow   *repo.Workflow
 	operator   []repo.WorkflowOperator
 	nodeInfo   *repo.WorkflowNode
-	Repo       EngineRepo
+	// 节点来源，已发布版本的工作流读取版本快照
+	nodes NodeSource
+	// 并行分支
+	tokens []repo.WorkflowToken
+	// 本次操作所在的分支，不在并行分支中时为nil
+	token *repo.WorkflowToken
+	Repo  EngineRepo
 	// 是否初始化
 	initialized bool
 	// 表单数据
 	formData map[string]interface{}
+	// 工作流附加数据
+	data map[string]interface{}
+	// 附加数据当前版本
+	dataVersion int
+	// 附加数据是否有修改
+	dataChanged bool
+	// 发起时关联的项目与任务
+	projectId uint
+	taskId    uint
 }
 
 type EngineRepo struct {
@@ -41,14 +63,39 @@ type EngineRepo struct {
 	workflowRepo         repo.WorkflowRepo
 	workflowOperatorRepo repo.WorkflowOperatorRepo
 	workflowNodeRepo     repo.WorkflowNodeRepo
+	workflowDataRepo     repo.WorkflowDataRepo
+	workflowLogRepo      repo.WorkflowLogRepo
+	workflowSequenceRepo repo.WorkflowSequenceRepo
+	workflowTokenRepo    repo.WorkflowTokenRepo
+	workflowCcRepo       repo.WorkflowCcRepo
+}
+
+// SetDbInstance 给所有Repo设置新的Orm实例
+func (r EngineRepo) SetDbInstance(tx *gorm.DB) {
+	r.workflowRepo.SetDbInstance(tx)
+	r.workflowNodeRepo.SetDbInstance(tx)
+	r.workflowOperatorRepo.SetDbInstance(tx)
+	r.workflowTypeRepo.SetDbInstance(tx)
+	r.workflowDataRepo.SetDbInstance(tx)
+	r.workflowLogRepo.SetDbInstance(tx)
+	r.workflowSequenceRepo.SetDbInstance(tx)
+	r.workflowTokenRepo.SetDbInstance(tx)
+	r.workflowCcRepo.SetDbInstance(tx)
 }
 
 // Open 打开一个工作流
-func Open(tx *gorm.DB, ctx *gin.Context, workflowId uint) (*Engine, error) {
-	workflowTypeRepo := data.NewWorkflowTypeRepo(tx, ctx)
-	workflowRepo := data.NewWorkflowRepo(tx, ctx)
-	workflowOperatorRepo := data.NewWorkflowOperatorRepo(tx, ctx)
-	workflowNodeRepo := data.NewWorkflowNodeRepo(tx, ctx)
+// actor 为本次操作的用户，系统任务可以传nil，执行需要操作人的动作前再通过 SetActor 设置
+func Open(tx *gorm.DB, ctx context.Context, actor *repo.User, workflowId uint) (*Engine, error) {
+	tx = tx.WithContext(ctx)
+	workflowTypeRepo := data.NewWorkflowTypeRepo(tx, nil)
+	workflowRepo := data.NewWorkflowRepo(tx, nil)
+	workflowOperatorRepo := data.NewWorkflowOperatorRepo(tx, nil)
+	workflowNodeRepo := data.NewWorkflowNodeRepo(tx, nil)
+	workflowDataRepo := data.NewWorkflowDataRepo(tx, nil)
+	workflowLogRepo := data.NewWorkflowLogRepo(tx, nil)
+	workflowSequenceRepo := data.NewWorkflowSequenceRepo(tx, nil)
+	workflowTokenRepo := data.NewWorkflowTokenRepo(tx, nil)
+	workflowCcRepo := data.NewWorkflowCcRepo(tx, nil)
 	// 查询工作流信息
 	workflow, err := workflowRepo.Get(workflowId)
 	if err != nil {
@@ -61,57 +108,112 @@ func Open(tx *gorm.DB, ctx *gin.Context, workflowId uint) (*Engine, error) {
 		return nil, err
 	}
 
+	// 使用发起时的定义版本，避免修改定义影响运行中的工作流
+	var nodes NodeSource = workflowNodeRepo
+	if workflow.Version > 0 {
+		typeData, nodes, err = loadVersion(tx, typeData, workflow.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 获取当前操作人(会有多个的情况，所以这里是Slice)
 	operator, err := workflowOperatorRepo.GetWorkflowOperatorByNode(workflowId, workflow.Node)
 	if err != nil {
 		return nil, err
 	}
 
-	// 获取当前节点信息
-	node, err := workflowNodeRepo.GetAppointNode(workflowId, workflow.Node)
+	// 获取当前节点信息，只有已结束的工作流允许当前节点不存在
+	node, err := nodes.GetAppointNode(workflow.TypeId, workflow.Node)
+	if err != nil {
+		ended := workflow.Node <= 0 || slice.Contain([]int{StatusVoided, StatusCompleted}, workflow.Status)
+		if !errors.Is(err, gorm.ErrRecordNotFound) || !ended {
+			return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+		}
+	}
 
-	// todo 获取工作流数据
+	// 获取并行分支
+	tokens, err := workflowTokenRepo.GetWorkflowTokens(workflowId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取工作流数据
+	workflowData := make(map[string]interface{})
+	dataVersion := 0
+	latest, err := workflowDataRepo.GetLatest(workflowId)
+	if err == nil {
+		if latest.Content != nil {
+			workflowData = latest.Content
+		}
+		dataVersion = latest.Version
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	// 设置属性
 	engine := &Engine{
 		Orm:         tx,
 		ctx:         ctx,
+		actor:       actor,
 		typeId:      typeData.ID,
 		typeData:    typeData,
 		workflowId:  workflow.ID,
 		workflow:    workflow,
 		operator:    operator,
 		nodeInfo:    node,
-		Repo:        EngineRepo{workflowTypeRepo: workflowTypeRepo, workflowRepo: workflowRepo, workflowOperatorRepo: workflowOperatorRepo, workflowNodeRepo: workflowNodeRepo},
+		nodes:       nodes,
+		tokens:      tokens,
+		Repo:        EngineRepo{workflowTypeRepo: workflowTypeRepo, workflowRepo: workflowRepo, workflowOperatorRepo: workflowOperatorRepo, workflowNodeRepo: workflowNodeRepo, workflowDataRepo: workflowDataRepo, workflowLogRepo: workflowLogRepo, workflowSequenceRepo: workflowSequenceRepo, workflowTokenRepo: workflowTokenRepo, workflowCcRepo: workflowCcRepo},
 		initialized: true,
 		formData:    make(map[string]interface{}),
+		data:        workflowData,
+		dataVersion: dataVersion,
 	}
 	return engine, nil
 }
 
 // Create 创建一个工作流
-func Create(tx *gorm.DB, ctx *gin.Context, typeId uint) (*Engine, error) {
-	workflowTypeRepo := data.NewWorkflowTypeRepo(tx, ctx)
-	workflowRepo := data.NewWorkflowRepo(tx, ctx)
-	workflowOperatorRepo := data.NewWorkflowOperatorRepo(tx, ctx)
-	workflowNodeRepo := data.NewWorkflowNodeRepo(tx, ctx)
+func Create(tx *gorm.DB, ctx context.Context, actor *repo.User, typeId uint) (*Engine, error) {
+	tx = tx.WithContext(ctx)
+	workflowTypeRepo := data.NewWorkflowTypeRepo(tx, nil)
+	workflowRepo := data.NewWorkflowRepo(tx, nil)
+	workflowOperatorRepo := data.NewWorkflowOperatorRepo(tx, nil)
+	workflowNodeRepo := data.NewWorkflowNodeRepo(tx, nil)
+	workflowDataRepo := data.NewWorkflowDataRepo(tx, nil)
+	workflowLogRepo := data.NewWorkflowLogRepo(tx, nil)
+	workflowSequenceRepo := data.NewWorkflowSequenceRepo(tx, nil)
+	workflowTokenRepo := data.NewWorkflowTokenRepo(tx, nil)
+	workflowCcRepo := data.NewWorkflowCcRepo(tx, nil)
 	// 查询工作流模板数据
 	typeData, err := workflowTypeRepo.Get(typeId)
 	if err != nil {
 		return nil, err
 	}
 
+	// 已发布的工作流类型使用当前生效的版本
+	var nodes NodeSource = workflowNodeRepo
+	if typeData.ActiveVersion > 0 {
+		typeData, nodes, err = loadVersion(tx, typeData, typeData.ActiveVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 设置属性
 	engine := &Engine{
 		Orm:         tx,
 		ctx:         ctx,
+		actor:       actor,
 		typeId:      typeId,
 		typeData:    typeData,
 		workflowId:  0,
 		workflow:    nil,
-		Repo:        EngineRepo{workflowTypeRepo: workflowTypeRepo, workflowRepo: workflowRepo, workflowOperatorRepo: workflowOperatorRepo, workflowNodeRepo: workflowNodeRepo},
+		nodes:       nodes,
+		Repo:        EngineRepo{workflowTypeRepo: workflowTypeRepo, workflowRepo: workflowRepo, workflowOperatorRepo: workflowOperatorRepo, workflowNodeRepo: workflowNodeRepo, workflowDataRepo: workflowDataRepo, workflowLogRepo: workflowLogRepo, workflowSequenceRepo: workflowSequenceRepo, workflowTokenRepo: workflowTokenRepo, workflowCcRepo: workflowCcRepo},
 		initialized: true,
 		formData:    make(map[string]interface{}),
+		data:        make(map[string]interface{}),
 	}
 
 	return engine, nil
@@ -125,32 +227,27 @@ func (engine *Engine) Initiate() error {
 	}
 
 	// 取当前用户
-	user, err := auth.CurrUser(engine.ctx)
+	user, err := engine.GetActor()
 	if err != nil {
 		return err
 	}
 
-	// todo 调用Hook
+	// 校验工作流数据
+	if err := engine.ValidateData(); err != nil {
+		return err
+	}
 
-	// 启动事务
-	transactionErr := engine.Orm.Transaction(func(tx *gorm.DB) error {
-		var err error
+	// 调用Hook，返回错误即否决发起
+	if err := engine.callHooks(HookBeforeInitiate); err != nil {
+		return err
+	}
 
-		engine.TransactionOrm = tx
-		defer func() {
-			engine.TransactionOrm = nil
-			// 还原所有Repo的Orm实例
-			engine.Repo.workflowRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowNodeRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowOperatorRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowTypeRepo.SetDbInstance(engine.Orm)
-		}()
+	// 需要通知的抄送人
+	var ccUsers []string
 
-		// 给所有Repo设置新的Orm实例
-		engine.Repo.workflowRepo.SetDbInstance(tx)
-		engine.Repo.workflowNodeRepo.SetDbInstance(tx)
-		engine.Repo.workflowOperatorRepo.SetDbInstance(tx)
-		engine.Repo.workflowTypeRepo.SetDbInstance(tx)
+	// 启动事务
+	transactionErr := engine.transaction(func() error {
+		var err error
 
 		// 生成序列号
 		serials, err := engine.GenerateSerials()
@@ -167,6 +264,9 @@ func (engine *Engine) Initiate() error {
 			Promoter:  user.ID,
 			Nickname:  user.UserNickname,
 			SubmitNum: 1, // 提交次数设置为1
+			Version:   engine.typeData.ActiveVersion,
+			ProjectId: engine.projectId,
+			TaskId:    engine.taskId,
 		}
 
 		// 设置工作流标题
@@ -181,12 +281,15 @@ func (engine *Engine) Initiate() error {
 		// 获取下一个节点配置
 		node, NextNodeErr := engine.NextNode()
 		if NextNodeErr != nil {
-			return err
+			return NextNodeErr
 		}
 		if node == nil {
 			// 没有下一个节点了，直接设定工作流为结束状态
 			workflow.Node = 0
 			workflow.Status = StatusCompleted
+			if err := engine.callHooks(HookBeforeComplete); err != nil {
+				return err
+			}
 		} else {
 			// 设置当前节点序号
 			workflow.Node = node.Node
@@ -198,31 +301,51 @@ func (engine *Engine) Initiate() error {
 		if err != nil {
 			return exception.NewException(response.WorkflowEngineSaveMainDataFail)
 		}
+		engine.workflowId = workflow.ID
+		engine.workflow = workflow
 
 		// 保存操作人
 		if workflow.Status == StatusRunning {
 			// 获取下一个节点操作人
 			operators, _ := engine.GetOperator(node)
-			for _, operator := range operators {
-				wo := repo.WorkflowOperator{
-					UserId:     operator.ID,
-					Nickname:   operator.UserNickname,
-					Node:       workflow.Node,
-					WorkflowId: workflow.ID,
-				}
-				err = engine.Repo.workflowOperatorRepo.Create(&wo)
-				if err != nil {
-					return exception.NewException(response.WorkflowEngineSaveOperatorFail)
+			for _, wo := range operators {
+				if err = engine.createOperator(wo, node); err != nil {
+					return err
 				}
 			}
+
+			// 抄送
+			if ccUsers, err = engine.carbonCopy(node); err != nil {
+				return err
+			}
 		}
 
 		// 尝试写入工作流附加数据
+		if len(engine.data) > 0 {
+			err = engine.saveData(workflow.ID, workflow.Node, user)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 执行钩子
+		afterEvents := []string{HookAfterInitiate}
+		if workflow.Status == StatusCompleted {
+			afterEvents = append(afterEvents, HookAfterComplete)
+		}
+		if err := engine.callHooks(afterEvents...); err != nil {
+			return err
+		}
 
 		// 记录日志
-		return nil
+		return engine.writeLog(LogActionInitiate, nil, node, user, "发起了工作流")
 	})
-	return transactionErr
+	if transactionErr != nil {
+		return transactionErr
+	}
+
+	engine.notifyCc(ccUsers)
+	return nil
 }
 
 // ExamineApprove 审批工作流
@@ -233,7 +356,7 @@ func (engine *Engine) ExamineApprove() error {
 	}
 
 	// 取当前用户
-	user, err := auth.CurrUser(engine.ctx)
+	user, err := engine.GetActor()
 	if err != nil {
 		return err
 	}
@@ -242,26 +365,120 @@ func (engine *Engine) ExamineApprove() error {
 		return exception.NewException(response.WorkflowEngineEnded)
 	}
 
-	// todo 调用Hook
+	// 动作为空时视为同意
+	action, _ := engine.formData["action"].(string)
+	if len(action) <= 0 {
+		action = LogActionNext
+	}
+	if !slice.Contain(examineActions, action) {
+		return exception.NewException(response.WorkflowEngineActionInvalid)
+	}
+	engine.formData["action"] = action
+
+	// 撤回由发起人操作，单独处理
+	if action == LogActionWithdraw {
+		return engine.Withdraw()
+	}
+
+	// 审批人修改了工作流数据
+	if engine.dataChanged {
+		if err := engine.ValidateData(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		nextNode  *repo.WorkflowNode
+		logAction string
+		vote      string
+		parallel  *parallelFlow
+	)
+	// 同意、驳回、加签与转交只能由当前节点未处理的操作人执行
+	byOperator := action != LogActionCancel
+	// 并行审批时切换到当前用户所在的分支
+	if engine.InParallel() && byOperator {
+		node, _ := engine.formData["node"].(int)
+		if err := engine.focusBranch(node, user.ID); err != nil {
+			return err
+		}
+	}
+	if byOperator && len(engine.operator) > 0 && !engine.IsOperator(user.ID) {
+		return exception.NewException(response.WorkflowEngineNotOperator)
+	}
 
-	var nextNode *repo.WorkflowNode
-	action, ok := engine.formData["action"]
-	if !ok || action == "next" {
+	switch action {
+	case LogActionAddSigner:
+		return engine.addSigner(user)
+	case LogActionTransfer:
+		return engine.transfer(user)
+	}
+	if byOperator {
+		// 前加签人只提交意见
+		if operator := engine.unhandledOperator(user.ID); operator != nil && operator.SignType == SignTypeBefore {
+			return engine.signOpinion(user, operator, action == LogActionOverrule)
+		}
+		// 自己发起的前加签还未处理完
+		if engine.pendingSigners(SignTypeBefore, user.ID, 0) {
+			return exception.NewException(response.WorkflowEngineSignerPending)
+		}
+	}
+	if action == LogActionNext {
+		logAction = LogActionNext
 		/* 工作流正常流转 */
-		// 如果当前工作流是 已驳回 状态
-		if engine.workflow.Status == StatusOverrule {
+		// 如果当前工作流是 已驳回 或 已撤回 状态，说明是重新提交
+		if engine.workflow.Status == StatusOverrule || engine.workflow.Status == StatusWithdrawn {
 			// 提交次数+1
 			engine.workflow.SubmitNum += 1
 		}
 
-		// 是否还有其他人没操作
-		if !engine.MultipleOperator(user.ID) {
-			// 获取下一个节点配置
-			node, NextNodeErr := engine.NextNode()
-			if NextNodeErr != nil {
+		// 是否达到节点的通过条件
+		vote = VoteApprove
+		if engine.countersign(user.ID, vote) == decisionPass {
+			forks, err := engine.forkNodes(engine.nodeInfo)
+			if err != nil {
 				return err
 			}
-			if node == nil {
+			if len(forks) > 0 && engine.token != nil {
+				return exception.NewException(response.WorkflowNodeParallelInvalid, "不支持在并行分支中再次分流")
+			}
+
+			// 获取下一个节点配置
+			var node *repo.WorkflowNode
+			if len(forks) <= 0 {
+				var NextNodeErr error
+				node, NextNodeErr = engine.NextNode()
+				if NextNodeErr != nil {
+					return NextNodeErr
+				}
+			}
+
+			if len(forks) > 0 || engine.token != nil {
+				/* 并行分支流转 */
+				targets := forks
+				if len(forks) <= 0 {
+					targets = []*repo.WorkflowNode{node}
+				}
+				parallel, err = engine.flowParallel(targets)
+				if err != nil {
+					return err
+				}
+
+				engine.workflow.Status = StatusRunning
+				if parallel.joined != nil {
+					// 汇聚完成，回到单一节点
+					engine.workflow.Node = parallel.joined.Node
+				} else if len(parallel.tokens) <= 0 {
+					// 所有分支都已结束
+					engine.workflow.Node = 0
+					engine.workflow.Status = StatusCompleted
+				} else if len(forks) > 0 {
+					// 分流后工作流停留在分流节点
+					engine.workflow.Node = engine.nodeInfo.Node
+				}
+				if len(parallel.activated) > 0 {
+					nextNode = parallel.activated[0]
+				}
+			} else if node == nil {
 				// 没有下一个节点了，直接设定工作流为结束状态
 				engine.workflow.Node = 0
 				engine.workflow.Status = StatusCompleted
@@ -272,12 +489,18 @@ func (engine *Engine) ExamineApprove() error {
 				nextNode = node
 			}
 		}
-	} else if action == "overrule" {
+	} else if action == LogActionOverrule && engine.countersign(user.ID, VoteReject) == decisionPending {
+		/* 反对票，等待其他人审批 */
+		logAction = LogActionReject
+		vote = VoteReject
+	} else if action == LogActionOverrule {
 		/* 驳回工作流 */
+		vote = VoteReject
 		// 是否跳转到指定节点
 		jumpNode, ok := engine.formData["jump_node"]
 		if ok {
-			node, err := engine.Repo.workflowNodeRepo.GetAppointNode(engine.typeId, jumpNode.(int))
+			logAction = LogActionJump
+			node, err := engine.nodes.GetAppointNode(engine.typeId, jumpNode.(int))
 			if err != nil {
 				return db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
 			}
@@ -289,8 +512,9 @@ func (engine *Engine) ExamineApprove() error {
 			engine.workflow.Node = node.Node
 			nextNode = node
 		} else {
+			logAction = LogActionOverrule
 			// 查询第一个节点
-			node, err := engine.Repo.workflowNodeRepo.FirstNode(engine.typeId)
+			node, err := engine.nodes.FirstNode(engine.typeId)
 			if err != nil {
 				return db.FirstQueryErrorHandle(err, response.WorkflowEngineNoFirstNodeSet)
 			}
@@ -300,37 +524,41 @@ func (engine *Engine) ExamineApprove() error {
 		}
 		// 设置工作流状态
 		engine.workflow.Status = StatusOverrule
-	} else if action == "cancel" {
+	} else if action == LogActionCancel {
 		/* 作废工作流 */
+		if !engine.canCancel(user) {
+			return exception.NewException(response.WorkflowEngineCancelDenied)
+		}
+		logAction = LogActionCancel
 		// 此操作不更改工作流节点
 		// 设置工作流状态
 		engine.workflow.Status = StatusVoided
 	}
 
+	// 调用Hook，返回错误即否决本次审批
+	beforeEvents, afterEvents := engine.hookEvents(logAction)
+	if err := engine.callHooks(beforeEvents...); err != nil {
+		return err
+	}
+
+	// 需要通知的抄送人
+	var ccUsers []string
+
 	// 启动事务
-	transactionErr := engine.Orm.Transaction(func(tx *gorm.DB) error {
+	transactionErr := engine.transaction(func() error {
 		var err error
 
-		engine.TransactionOrm = tx
-		defer func() {
-			engine.TransactionOrm = nil
-			// 还原所有Repo的Orm实例
-			engine.Repo.workflowRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowNodeRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowOperatorRepo.SetDbInstance(engine.Orm)
-			engine.Repo.workflowTypeRepo.SetDbInstance(engine.Orm)
-		}()
+		// 需要生成操作人的节点
+		var activated []*repo.WorkflowNode
 
-		// 给所有Repo设置新的Orm实例
-		engine.Repo.workflowRepo.SetDbInstance(tx)
-		engine.Repo.workflowNodeRepo.SetDbInstance(tx)
-		engine.Repo.workflowOperatorRepo.SetDbInstance(tx)
-		engine.Repo.workflowTypeRepo.SetDbInstance(tx)
-
-		// todo 执行钩子
+		// 记录当前操作人的投票
+		if len(vote) > 0 {
+			err := engine.Repo.workflowOperatorRepo.SetVote(engine.workflowId, engine.nodeInfo.Node, user.ID, vote, carbon.Now().TimestampMilli())
+			if err != nil {
+				return exception.NewException(response.WorkflowEngineOperatorHandleFail)
+			}
+		}
 
-		// 下一个节点的操作人
-		var operators []repo.User
 		/* 判断工作流状态 Start */
 		if engine.workflow.Status == StatusCompleted {
 			/* 工作流已完成，删除该工作流的所有操作人 */
@@ -338,28 +566,33 @@ func (engine *Engine) ExamineApprove() error {
 			if err != nil {
 				return exception.NewException(response.WorkflowEngineRemoveOperatorFail)
 			}
-		} else if engine.workflow.Status == StatusOverrule {
+		} else if logAction == LogActionOverrule || logAction == LogActionJump {
 			/* 被驳回 */
 			// 删除该工作流的所有操作人
 			err := engine.Repo.workflowOperatorRepo.RemoveWorkflowAllOperator(engine.workflowId)
 			if err != nil {
 				return exception.NewException(response.WorkflowEngineRemoveOperatorFail)
 			}
-			// 获取节点操作人
-			operators, _ = engine.GetOperator(nextNode)
-		} else if engine.workflow.Node == engine.nodeInfo.Node {
-			/* 如果工作流步骤没有改变，则尝试将当前操作人改为已确认 */
-			err := engine.Repo.workflowOperatorRepo.SetHandled(engine.workflowId, engine.nodeInfo.Node, user.ID)
-			if err != nil {
-				return exception.NewException(response.WorkflowEngineOperatorHandleFail)
-			}
-		} else {
+			activated = []*repo.WorkflowNode{nextNode}
+		} else if parallel != nil {
+			/* 并行分支流转 */
+			activated = parallel.activated
+		} else if nextNode != nil {
 			/* 进入下一步 */
-			// 获取节点操作人
-			operators, _ = engine.GetOperator(nextNode)
+			activated = []*repo.WorkflowNode{nextNode}
 		}
 		/* 判断工作流状态 End */
 
+		// 保存并行分支，工作流离开审批中状态时删除所有分支
+		if parallel != nil {
+			err = engine.Repo.workflowTokenRepo.Replace(engine.workflowId, parallel.tokens)
+		} else if engine.InParallel() && engine.workflow.Status != StatusRunning {
+			err = engine.Repo.workflowTokenRepo.Replace(engine.workflowId, nil)
+		}
+		if err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
 		// 更新主表数据
 		err = engine.Repo.workflowRepo.Save(engine.workflow)
 		if err != nil {
@@ -367,51 +600,198 @@ func (engine *Engine) ExamineApprove() error {
 		}
 
 		// 保存操作人
-		if len(operators) > 0 {
-			for _, operator := range operators {
-				wo := repo.WorkflowOperator{
-					UserId:     operator.ID,
-					Nickname:   operator.UserNickname,
-					Node:       engine.workflow.Node,
-					WorkflowId: engine.workflowId,
-				}
-				err = engine.Repo.workflowOperatorRepo.Create(&wo)
-				if err != nil {
-					return exception.NewException(response.WorkflowEngineSaveOperatorFail)
+		for _, node := range activated {
+			// 获取节点操作人
+			operators, _ := engine.GetOperator(node)
+			for _, wo := range operators {
+				if err = engine.createOperator(wo, node); err != nil {
+					return err
 				}
 			}
+
+			// 抄送
+			nodeCcUsers, err := engine.carbonCopy(node)
+			if err != nil {
+				return err
+			}
+			ccUsers = append(ccUsers, nodeCcUsers...)
 		}
 
 		// 尝试写入工作流附加数据
+		if engine.dataChanged {
+			err = engine.saveData(engine.workflowId, engine.nodeInfo.Node, user)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 执行钩子
+		if err := engine.callHooks(afterEvents...); err != nil {
+			return err
+		}
 
 		// 记录日志
-		return nil
+		return engine.writeLog(logAction, engine.nodeInfo, engine.targetNode(nextNode), user, "")
+	})
+	if transactionErr != nil {
+		return transactionErr
+	}
+
+	engine.notifyCc(ccUsers)
+	return nil
+}
+
+// Withdraw 发起人撤回工作流
+// 仅允许在当前节点还没有人处理时撤回，撤回后工作流回到第一个节点，由发起人重新提交
+func (engine *Engine) Withdraw() error {
+	// 检查是否初始化
+	if !engine.initialized {
+		return exception.NewException(response.WorkflowEngineNotInitialized)
+	}
+
+	// 取当前用户
+	user, err := engine.GetActor()
+	if err != nil {
+		return err
+	}
+
+	if engine.IsEnd() {
+		return exception.NewException(response.WorkflowEngineEnded)
+	}
+
+	// 只有发起人可以撤回
+	if engine.workflow.Promoter != user.ID {
+		return exception.NewException(response.WorkflowEngineNotPromoter)
+	}
+
+	// 只有审批中的工作流可以撤回
+	if engine.workflow.Status != StatusRunning {
+		return exception.NewException(response.WorkflowEngineWithdrawDenied, "工作流不在审批中，无法撤回")
+	}
+	// 当前节点已经有人处理过则不允许撤回
+	for _, operator := range engine.operator {
+		if operator.Handled == 1 {
+			return exception.NewException(response.WorkflowEngineWithdrawDenied)
+		}
+	}
+
+	// 查询第一个节点
+	firstNode, err := engine.nodes.FirstNode(engine.typeId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.WorkflowEngineNoFirstNodeSet)
+	}
+
+	// 调用Hook，返回错误即否决撤回
+	if err := engine.callHooks(HookBeforeWithdraw); err != nil {
+		return err
+	}
+
+	// 启动事务
+	transactionErr := engine.transaction(func() error {
+		// 删除该工作流的所有操作人
+		err := engine.Repo.workflowOperatorRepo.RemoveWorkflowAllOperator(engine.workflowId)
+		if err != nil {
+			return exception.NewException(response.WorkflowEngineRemoveOperatorFail)
+		}
+
+		// 删除所有并行分支
+		if engine.InParallel() {
+			if err := engine.Repo.workflowTokenRepo.Replace(engine.workflowId, nil); err != nil {
+				return exception.ErrorHandle(err, response.DbExecuteError)
+			}
+		}
+
+		// 回到第一个节点
+		engine.workflow.Node = firstNode.Node
+		engine.workflow.Status = StatusWithdrawn
+		err = engine.Repo.workflowRepo.Save(engine.workflow)
+		if err != nil {
+			return exception.NewException(response.WorkflowEngineSaveMainDataFail)
+		}
+
+		// 由发起人重新提交
+		err = engine.createOperator(repo.WorkflowOperator{
+			UserId:   engine.workflow.Promoter,
+			Nickname: engine.workflow.Nickname,
+		}, firstNode)
+		if err != nil {
+			return err
+		}
+
+		// 执行钩子
+		if err := engine.callHooks(HookAfterWithdraw); err != nil {
+			return err
+		}
+
+		// 记录日志
+		return engine.writeLog(LogActionWithdraw, engine.nodeInfo, firstNode, user, "")
 	})
 	return transactionErr
 }
 
+// createOperator 保存节点的操作人，节点设置了超时时间时记录审批截止时间
+func (engine *Engine) createOperator(wo repo.WorkflowOperator, node *repo.WorkflowNode) error {
+	wo.Node = node.Node
+	wo.WorkflowId = engine.workflowId
+	if node.Timeout > 0 {
+		wo.Deadline = carbon.Now().TimestampMilli() + int64(node.Timeout)*60*1000
+	}
+	if err := engine.Repo.workflowOperatorRepo.Create(&wo); err != nil {
+		return exception.NewException(response.WorkflowEngineSaveOperatorFail)
+	}
+	return nil
+}
+
 // NextNode 获取当前工作流的下一个节点
+// 当前节点配置了条件分支时，按分支流转，否则按节点序号流转
 func (engine *Engine) NextNode() (*repo.WorkflowNode, error) {
-	// 假设当前节点是0
-	var currNode = 0
-	// 先获取当前工作流的节点
-	if engine.workflow != nil {
-		currNode = engine.workflow.Node
-	}
+	var (
+		currNode *repo.WorkflowNode
+		err      error
+	)
 	// 如果节点序号小于等于0，表示该工作流还没有正式发起
-	if currNode <= 0 {
+	if engine.token != nil {
+		// 并行分支中以分支所在节点为当前节点
+		currNode = engine.nodeInfo
+	} else if engine.workflow == nil || engine.workflow.Node <= 0 {
 		// 获取第一个节点
-		node, err := engine.Repo.workflowNodeRepo.GetNextNode(engine.typeId, 0)
+		currNode, err = engine.nodes.GetNextNode(engine.typeId, 0)
 		if err != nil {
 			// 第一个节点未设置
 			return nil, db.FirstQueryErrorHandle(err, response.WorkflowEngineNoFirstNodeSet)
 		}
-		// 把第一个节点的序号赋值给 currNode
-		currNode = node.Node
+	} else {
+		// 获取当前节点
+		currNode, err = engine.nodes.GetAppointNode(engine.typeId, engine.workflow.Node)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+		}
+	}
+
+	// 节点自定义条件判断
+	branch, err := engine.MatchBranch(currNode)
+	if err != nil {
+		return nil, err
+	}
+	if branch != nil {
+		// 分支指向结束
+		if branch.Node <= 0 {
+			return nil, nil
+		}
+		node, err := engine.nodes.GetAppointNode(engine.typeId, branch.Node)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+		}
+		return node, nil
+	}
+
+	// 并行分支中不能按序号进入其它分支
+	if engine.token != nil {
+		return engine.nextInBranch(currNode)
 	}
 
 	// 获取下一个节点配置
-	node, err := engine.Repo.workflowNodeRepo.GetNextNode(engine.typeId, currNode)
+	node, err := engine.nodes.GetNextNode(engine.typeId, currNode.Node)
 	if err != nil {
 		// 如果只是没有记录，两个参数都返回nil
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -419,22 +799,99 @@ func (engine *Engine) NextNode() (*repo.WorkflowNode, error) {
 		}
 		return nil, err
 	}
-	// todo 节点自定义条件判断，以后再说吧
 	return node, nil
 }
 
-func (engine *Engine) GetOperator(workflowNode *repo.WorkflowNode) ([]repo.User, error) {
+// nextInBranch 并行分支中按序号获取下一个节点
+// 下一个节点是其它分支的第一个节点时，说明当前分支已结束，跳过其它分支直接进入汇聚节点
+// 并行期间工作流停留在分流节点，以此获取所有分支的第一个节点
+func (engine *Engine) nextInBranch(currNode *repo.WorkflowNode) (*repo.WorkflowNode, error) {
+	forkNode, err := engine.nodes.GetAppointNode(engine.typeId, engine.workflow.Node)
+	if err != nil {
+		return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+	}
+	heads, err := ParseFork(forkNode.Fork)
+	if err != nil {
+		return nil, err
+	}
+
+	inBranch := true
+	node := currNode
+	for {
+		node, err = engine.nodes.GetNextNode(engine.typeId, node.Node)
+		if err != nil {
+			// 没有汇聚节点，分支直接结束
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if node.JoinMode != "" {
+			return node, nil
+		}
+		if slice.Contain(heads, node.Node) {
+			inBranch = false
+		}
+		if inBranch {
+			return node, nil
+		}
+	}
+}
+
+// MatchBranch 匹配节点的条件分支
+// 返回第一个满足条件的分支，都不满足时返回默认分支，没有可用的分支时返回nil
+func (engine *Engine) MatchBranch(node *repo.WorkflowNode) (*NodeBranch, error) {
+	branches, err := ParseBranches(node.Branches)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultBranch *NodeBranch
+	for i, branch := range branches {
+		// 条件为空的是默认分支，只取第一个
+		if len(strings.TrimSpace(branch.Condition)) <= 0 {
+			if defaultBranch == nil {
+				defaultBranch = &branches[i]
+			}
+			continue
+		}
+
+		condition, err := ParseCondition(branch.Condition)
+		if err != nil {
+			return nil, err
+		}
+		if condition.Evaluate(engine.conditionData()) {
+			return &branches[i], nil
+		}
+	}
+
+	return defaultBranch, nil
+}
+
+// GetOperator 获取节点的操作人
+// 操作人设置了委托规则时，替换为代理人
+func (engine *Engine) GetOperator(workflowNode *repo.WorkflowNode) ([]repo.WorkflowOperator, error) {
+	users, err := engine.nodeUsers(workflowNode)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.delegate(users)
+}
+
+// nodeUsers 获取节点配置的操作用户
+// ActionValue 为json数组时直接指定用户，否则作为节点动作的配置
+func (engine *Engine) nodeUsers(workflowNode *repo.WorkflowNode) ([]repo.User, error) {
 	var (
 		userList = make([]repo.User, 0)
 		err      error
 	)
 
 	// 实例化UserRepo
-	userRepo := data.NewUserRepo(engine.GetCorrectOrm(), engine.ctx)
+	userRepo := data.NewUserRepo(engine.GetCorrectOrm(), nil)
 
-	// 如果直接指定了用户
-	if len(workflowNode.ActionValue) > 0 {
-		// 只能是json格式字符串
+	if strings.HasPrefix(strings.TrimSpace(workflowNode.ActionValue), "[") {
+		// 如果直接指定了用户
 		var fp fastjson.Parser
 		fpv, err := fp.Parse(workflowNode.ActionValue)
 		if err != nil {
@@ -448,24 +905,23 @@ func (engine *Engine) GetOperator(workflowNode *repo.WorkflowNode) ([]repo.User,
 			}
 			userList = append(userList, *u)
 		}
-
-		return userList, err
-	}
-
-	// 处理Action
-	if len(workflowNode.Action) > 0 {
+	} else if len(workflowNode.Action) > 0 {
+		// 处理Action
 		nodeAction, err := GetAction(workflowNode.Action)
 		if err != nil {
 			return nil, err
 		}
 
-		userList, err = nodeAction.Handle(engine)
+		userList, err = nodeAction.Handle(engine, workflowNode)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// 如果用户列表还是空的，取当前登录人
 	if len(userList) <= 0 {
 		var u *repo.User
-		u, err = auth.CurrUser(engine.ctx)
+		u, err = engine.GetActor()
 		if err != nil {
 			return nil, err
 		}
@@ -476,21 +932,102 @@ func (engine *Engine) GetOperator(workflowNode *repo.WorkflowNode) ([]repo.User,
 	return userList, nil
 }
 
+// delegate 根据委托规则将操作人替换为代理人
+// 代理人本身就是该节点的操作人时，不再重复添加
+func (engine *Engine) delegate(users []repo.User) ([]repo.WorkflowOperator, error) {
+	delegationRepo := data.NewWorkflowDelegationRepo(engine.GetCorrectOrm(), nil)
+	now := carbon.Now().TimestampMilli()
+
+	exists := make(map[uint64]bool, len(users))
+	for _, user := range users {
+		exists[user.ID] = true
+	}
+
+	operators := make([]repo.WorkflowOperator, 0, len(users))
+	for _, user := range users {
+		operator := repo.WorkflowOperator{
+			UserId:   user.ID,
+			Nickname: user.UserNickname,
+		}
+
+		rules, err := delegationRepo.GetActive(user.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			if rule.DelegateId == user.ID || !rule.Match(engine.typeId) {
+				continue
+			}
+			operator.OriginalUserId = user.ID
+			operator.OriginalNickname = user.UserNickname
+			operator.UserId = rule.DelegateId
+			operator.Nickname = rule.DelegateNickname
+			break
+		}
+
+		if operator.OriginalUserId > 0 {
+			if exists[operator.UserId] {
+				continue
+			}
+			exists[operator.UserId] = true
+		}
+		operators = append(operators, operator)
+	}
+
+	return operators, nil
+}
+
+// GenerateSerials 生成工作流编号
+// 编号格式为 前缀 + 日期 + 序号，序号在日期变化时重置
+// 序号通过行锁递增，必须在事务中调用
 func (engine *Engine) GenerateSerials() (string, error) {
-	start := carbon.Now().StartOfDay().TimestampMilli()
-	end := carbon.Now().EndOfDay().TimestampMilli()
-	// 获取当天的开始与结束
-	// 这里暂时不考虑并发的情况
-	total, err := engine.Repo.workflowRepo.GetDayTotal(start, end)
+	serialConfig := config.Get().Workflow.Serial
+	prefix := serialConfig.Prefix
+	dateFormat := serialConfig.DateFormat
+	padding := serialConfig.Padding
+	// 工作流类型的模板优先
+	if engine.typeData.SerialPrefix != "" {
+		prefix = engine.typeData.SerialPrefix
+	}
+	if engine.typeData.SerialDateFormat != "" {
+		dateFormat = engine.typeData.SerialDateFormat
+	}
+	if engine.typeData.SerialPadding > 0 {
+		padding = engine.typeData.SerialPadding
+	}
+
+	// 独立计数的类型使用自己的序列，否则共用全局序列
+	scope := ""
+	if engine.typeData.SerialIndependent == 1 {
+		scope = engine.typeData.OnlyName
+	}
+
+	date := time.Now().Format(dateFormat)
+	value, err := engine.Repo.workflowSequenceRepo.Next(scope, date)
 	if err != nil {
 		return "", exception.ErrorHandle(err, response.WorkflowEngineSerialGenerationFailed)
 	}
 
-	// 用 0 填充total为 4 位长度的字符串
-	// total 要先 +1
-	index := strutil.PadStart(strconv.FormatInt(total+1, 10), 4, "0")
-	// 例子: 20230807 + <random number>*3 + 0001
-	return carbon.Now().ToShortDateString() + strconv.Itoa(random.RandInt(100, 999)) + index, nil
+	// 用 0 填充序号，例子: 20230807 + 0001
+	index := strutil.PadStart(strconv.FormatInt(value, 10), padding, "0")
+	return prefix + date + index, nil
+}
+
+// transaction 在事务中执行fn，事务期间所有Repo使用事务的Orm实例
+func (engine *Engine) transaction(fn func() error) error {
+	return engine.Orm.Transaction(func(tx *gorm.DB) error {
+		engine.TransactionOrm = tx
+		defer func() {
+			engine.TransactionOrm = nil
+			// 还原所有Repo的Orm实例
+			engine.Repo.SetDbInstance(engine.Orm)
+		}()
+
+		// 给所有Repo设置新的Orm实例
+		engine.Repo.SetDbInstance(tx)
+
+		return fn()
+	})
 }
 
 func (engine *Engine) GetCorrectOrm() *gorm.DB {
@@ -501,6 +1038,56 @@ func (engine *Engine) GetCorrectOrm() *gorm.DB {
 	}
 }
 
+// GetWorkflow 获取工作流主数据，发起前为nil
+func (engine *Engine) GetWorkflow() *repo.Workflow {
+	return engine.workflow
+}
+
+// GetTypeData 获取工作流类型
+func (engine *Engine) GetTypeData() *repo.WorkflowType {
+	return engine.typeData
+}
+
+// GetContext 获取上下文
+func (engine *Engine) GetContext() context.Context {
+	return engine.ctx
+}
+
+// GetActor 获取本次操作的用户
+func (engine *Engine) GetActor() (*repo.User, error) {
+	if engine.actor == nil {
+		return nil, exception.NewException(response.WorkflowEngineNoActor)
+	}
+	return engine.actor, nil
+}
+
+// SetActor 设置本次操作的用户
+func (engine *Engine) SetActor(actor *repo.User) {
+	engine.actor = actor
+}
+
+// SetRelation 设置发起时关联的项目与任务，只在发起前有效
+func (engine *Engine) SetRelation(projectId, taskId uint) {
+	engine.projectId = projectId
+	engine.taskId = taskId
+}
+
+// GetProjectId 获取关联的项目ID，发起后读取工作流主数据
+func (engine *Engine) GetProjectId() uint {
+	if engine.workflow != nil && engine.workflow.ID > 0 {
+		return engine.workflow.ProjectId
+	}
+	return engine.projectId
+}
+
+// GetTaskId 获取关联的任务ID，发起后读取工作流主数据
+func (engine *Engine) GetTaskId() uint {
+	if engine.workflow != nil && engine.workflow.ID > 0 {
+		return engine.workflow.TaskId
+	}
+	return engine.taskId
+}
+
 func (engine *Engine) SetFormData(in map[string]interface{}) {
 	engine.formData = in
 }
@@ -509,6 +1096,209 @@ func (engine *Engine) SetFormDataField(key string, value interface{}) {
 	engine.formData[key] = value
 }
 
+// SetData 设置工作流附加数据
+// 与已有数据合并，相同的键会被覆盖，保存时生成新版本
+func (engine *Engine) SetData(in map[string]interface{}) {
+	for key, value := range in {
+		engine.data[key] = value
+		engine.dataChanged = true
+	}
+}
+
+// GetData 获取工作流附加数据
+func (engine *Engine) GetData() map[string]interface{} {
+	return engine.data
+}
+
+// ValidateData 使用工作流类型的表单结构校验附加数据
+func (engine *Engine) ValidateData() error {
+	fields, err := ParseFormSchema(engine.typeData.FormSchema)
+	if err != nil {
+		return err
+	}
+	return ValidateFormData(fields, engine.data)
+}
+
+// AmendData 修改工作流附加数据，不改变工作流状态
+// 只有当前节点未处理的操作人可以修改
+func (engine *Engine) AmendData() error {
+	// 检查是否初始化
+	if !engine.initialized {
+		return exception.NewException(response.WorkflowEngineNotInitialized)
+	}
+
+	// 取当前用户
+	user, err := engine.GetActor()
+	if err != nil {
+		return err
+	}
+
+	if engine.IsEnd() {
+		return exception.NewException(response.WorkflowEngineEnded)
+	}
+
+	if !engine.IsOperator(user.ID) {
+		return exception.NewException(response.WorkflowEngineNotOperator)
+	}
+
+	// 没有修改
+	if !engine.dataChanged {
+		return nil
+	}
+
+	if err := engine.ValidateData(); err != nil {
+		return err
+	}
+
+	return engine.transaction(func() error {
+		err := engine.saveData(engine.workflowId, engine.workflow.Node, user)
+		if err != nil {
+			return err
+		}
+
+		return engine.writeLog(LogActionAmend, engine.nodeInfo, engine.nodeInfo, user, fmt.Sprintf("修改了工作流数据(版本%d)", engine.dataVersion))
+	})
+}
+
+// saveData 保存工作流附加数据，每次保存生成一个新版本
+func (engine *Engine) saveData(workflowId uint, node int, user *repo.User) error {
+	content, err := json.Marshal(engine.data)
+	if err != nil {
+		return exception.ErrorHandle(err, response.WorkflowEngineSaveDataFail)
+	}
+
+	record := &repo.WorkflowData{
+		WorkflowId: workflowId,
+		Version:    engine.dataVersion + 1,
+		Node:       node,
+		Operator:   user.ID,
+		Nickname:   user.UserNickname,
+		Data:       string(content),
+	}
+	err = engine.Repo.workflowDataRepo.Create(record)
+	if err != nil {
+		return exception.ErrorHandle(err, response.WorkflowEngineSaveDataFail)
+	}
+
+	engine.dataVersion = record.Version
+	engine.dataChanged = false
+	return nil
+}
+
+// hookEvents 根据审批动作获取需要执行的 before 与 after 钩子事件
+func (engine *Engine) hookEvents(logAction string) ([]string, []string) {
+	switch logAction {
+	case LogActionOverrule, LogActionJump:
+		return []string{HookBeforeOverrule}, []string{HookAfterOverrule}
+	case LogActionCancel:
+		return []string{HookBeforeCancel}, []string{HookAfterCancel}
+	case LogActionReject:
+		// 反对票不改变工作流状态
+		return nil, nil
+	}
+
+	before, after := []string{HookBeforeApprove}, []string{HookAfterApprove}
+	if engine.workflow.Status == StatusCompleted {
+		before = append(before, HookBeforeComplete)
+		after = append(after, HookAfterComplete)
+	}
+	return before, after
+}
+
+// targetNode 审批后工作流所在的节点，工作流结束时返回nil
+func (engine *Engine) targetNode(nextNode *repo.WorkflowNode) *repo.WorkflowNode {
+	if engine.workflow.Status == StatusCompleted {
+		return nil
+	}
+	if nextNode != nil {
+		return nextNode
+	}
+	// 节点未改变
+	return engine.nodeInfo
+}
+
+// writeLog 记录工作流日志，需要在事务中调用
+// message 为空时根据操作类型生成
+func (engine *Engine) writeLog(action string, from, to *repo.WorkflowNode, user *repo.User, message string) error {
+	logData := &repo.WorkflowLog{
+		WorkflowId:  engine.workflowId,
+		Action:      action,
+		Operator:    user.ID,
+		Nickname:    user.UserNickname,
+		OperateTime: carbon.Now().TimestampMilli(),
+	}
+	if from != nil {
+		logData.Node = from.Node
+		logData.NodeName = from.Name
+	}
+	if to != nil {
+		logData.TargetNode = to.Node
+		logData.TargetNodeName = to.Name
+	}
+	// 审批意见
+	if comment, ok := engine.formData["comment"].(string); ok {
+		logData.Comment = comment
+	}
+	// 系统代替操作人处理，记录为系统代该操作人处理
+	if engine.bySystem {
+		logData.Operator = systemUser.ID
+		logData.Nickname = systemUser.UserNickname
+		logData.OnBehalfOf = user.ID
+		logData.OnBehalfNickname = user.UserNickname
+	} else if action != LogActionInitiate && action != LogActionWithdraw {
+		// 代理审批，记录被代理人
+		for _, operator := range engine.operator {
+			if operator.UserId == user.ID && operator.OriginalUserId > 0 {
+				logData.OnBehalfOf = operator.OriginalUserId
+				logData.OnBehalfNickname = operator.OriginalNickname
+				break
+			}
+		}
+	}
+
+	if len(message) <= 0 {
+		switch action {
+		case LogActionNext:
+			if to == nil {
+				message = "审批通过，工作流已完成"
+			} else if from != nil && from.Node == to.Node {
+				message = "审批通过，等待其他人审批"
+			} else {
+				message = fmt.Sprintf("审批通过，流转到节点[%s]", to.Name)
+			}
+		case LogActionOverrule:
+			message = fmt.Sprintf("驳回到节点[%s]", logData.TargetNodeName)
+		case LogActionJump:
+			message = fmt.Sprintf("驳回到指定节点[%s]", logData.TargetNodeName)
+		case LogActionReject:
+			message = "投了反对票，等待其他人审批"
+		case LogActionCancel:
+			message = "作废了工作流"
+		case LogActionWithdraw:
+			message = "撤回了工作流"
+		}
+	}
+	if logData.OnBehalfOf > 0 {
+		message = fmt.Sprintf("%s（代%s处理）", message, logData.OnBehalfNickname)
+	}
+	logData.Message = message
+
+	err := engine.Repo.workflowLogRepo.Create(logData)
+	return exception.ErrorHandle(err, response.WorkflowEngineSaveLogFail)
+}
+
+// conditionData 条件判断使用的数据，附加数据优先
+func (engine *Engine) conditionData() map[string]interface{} {
+	m := make(map[string]interface{}, len(engine.formData)+len(engine.data))
+	for key, value := range engine.formData {
+		m[key] = value
+	}
+	for key, value := range engine.data {
+		m[key] = value
+	}
+	return m
+}
+
 // IsEnd 工作流是否已结束
 func (engine *Engine) IsEnd() bool {
 	if engine.workflow == nil {
@@ -522,16 +1312,24 @@ func (engine *Engine) IsEnd() bool {
 	return false
 }
 
-// MultipleOperator 是否还有其它操作人
-func (engine *Engine) MultipleOperator(userId uint64) bool {
-	if engine.nodeInfo.Everyone == 1 {
-		b, err := engine.Repo.workflowOperatorRepo.OtherOperator(engine.workflowId, engine.nodeInfo.Node, userId)
-		if err != nil {
-			_ = exception.ErrorHandle(err, response.DbQueryError)
-			return false
-		}
-		return b
+// canCancel 只有发起人、管理员与当前节点未处理的操作人可以作废工作流
+// 并行审批时切换到该用户所在的分支
+func (engine *Engine) canCancel(user *repo.User) bool {
+	if engine.workflow.Promoter == user.ID || user.Super == 1 {
+		return true
+	}
+	if engine.InParallel() {
+		return engine.focusBranch(0, user.ID) == nil
 	}
+	return engine.IsOperator(user.ID)
+}
 
+// IsOperator 是否当前节点未处理的操作人
+func (engine *Engine) IsOperator(userId uint64) bool {
+	for _, operator := range engine.operator {
+		if operator.UserId == userId && operator.Handled == 0 {
+			return true
+		}
+	}
 	return false
 }
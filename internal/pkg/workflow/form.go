@@ -0,0 +1,154 @@
+package workflow
+
+import (
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	FieldTypeString  = "string"
+	FieldTypeNumber  = "number"
+	FieldTypeInteger = "integer"
+	FieldTypeBoolean = "boolean"
+	FieldTypeDate    = "date"
+	FieldTypeArray   = "array"
+)
+
+// FormField 表单字段定义
+type FormField struct {
+	// 字段名，对应表单数据的键
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// 字段类型
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// 可选值，为空表示不限制
+	Enum []interface{} `json:"enum,omitempty"`
+}
+
+// GetFieldTypes 获取支持的字段类型
+func GetFieldTypes() []string {
+	return []string{FieldTypeString, FieldTypeNumber, FieldTypeInteger, FieldTypeBoolean, FieldTypeDate, FieldTypeArray}
+}
+
+// ParseFormSchema 解析表单结构
+// 结构为json数组字符串，例如 [{"name":"amount","label":"金额","type":"number","required":true}]
+func ParseFormSchema(s string) ([]FormField, error) {
+	fields := make([]FormField, 0)
+	if len(strings.TrimSpace(s)) <= 0 {
+		return fields, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &fields); err != nil {
+		return nil, exception.NewException(response.WorkflowFormSchemaInvalid)
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if len(strings.TrimSpace(field.Name)) <= 0 {
+			return nil, exception.NewException(response.WorkflowFormSchemaInvalid, "表单字段名不能为空")
+		}
+		if slice.Contain(names, field.Name) {
+			return nil, exception.NewException(response.WorkflowFormSchemaInvalid, fmt.Sprintf("表单字段[%s]重复", field.Name))
+		}
+		if !slice.Contain(GetFieldTypes(), field.Type) {
+			return nil, exception.NewException(response.WorkflowFormSchemaInvalid, fmt.Sprintf("表单字段[%s]类型错误", field.Name))
+		}
+		names = append(names, field.Name)
+	}
+
+	return fields, nil
+}
+
+// ValidateFormData 使用表单结构校验表单数据
+// 表单结构以外的字段不做校验
+func ValidateFormData(fields []FormField, formData map[string]interface{}) error {
+	for _, field := range fields {
+		label := field.Label
+		if len(label) <= 0 {
+			label = field.Name
+		}
+
+		value, ok := formData[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required {
+				return exception.NewException(response.WorkflowFormDataInvalid, fmt.Sprintf("字段[%s]为必填项", label))
+			}
+			continue
+		}
+
+		if !checkFieldType(field.Type, value) {
+			return exception.NewException(response.WorkflowFormDataInvalid, fmt.Sprintf("字段[%s]类型错误", label))
+		}
+
+		if len(field.Enum) > 0 && !inEnum(field.Enum, value) {
+			return exception.NewException(response.WorkflowFormDataInvalid, fmt.Sprintf("字段[%s]的值不在可选范围内", label))
+		}
+	}
+	return nil
+}
+
+func checkFieldType(t string, value interface{}) bool {
+	switch t {
+	case FieldTypeString:
+		_, ok := value.(string)
+		return ok
+	case FieldTypeNumber:
+		if _, ok := value.(string); ok {
+			return false
+		}
+		_, ok := toFloat(value)
+		return ok
+	case FieldTypeInteger:
+		if _, ok := value.(string); ok {
+			return false
+		}
+		f, ok := toFloat(value)
+		return ok && f == math.Trunc(f)
+	case FieldTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case FieldTypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	// 数组类型要求每个元素都在可选范围内
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if !inEnum(enum, item) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, option := range enum {
+		if a, aOk := toFloat(option); aOk {
+			if b, bOk := toFloat(value); bOk && a == b {
+				return true
+			}
+			continue
+		}
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
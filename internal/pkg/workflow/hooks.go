@@ -1 +1,67 @@
 package workflow
+
+const (
+	HookBeforeInitiate = "before_initiate"
+	HookAfterInitiate  = "after_initiate"
+	HookBeforeApprove  = "before_approve"
+	HookAfterApprove   = "after_approve"
+	HookBeforeOverrule = "before_overrule"
+	HookAfterOverrule  = "after_overrule"
+	HookBeforeCancel   = "before_cancel"
+	HookAfterCancel    = "after_cancel"
+	HookBeforeWithdraw = "before_withdraw"
+	HookAfterWithdraw  = "after_withdraw"
+	HookBeforeComplete = "before_complete"
+	HookAfterComplete  = "after_complete"
+
+	// HookAllType 注册到所有工作流类型
+	HookAllType = "*"
+)
+
+// Hook 工作流钩子
+// before 钩子在事务开始前执行，返回错误即可否决本次操作
+// after 钩子在事务内执行，返回错误会回滚整个操作
+type Hook interface {
+	HookName() string
+	Handle(engine *Engine, event string) error
+}
+
+// HookPool 已注册的钩子
+// 第一层的Key为工作流类型的 OnlyName，第二层的Key为事件名称
+var HookPool = make(map[string]map[string][]Hook)
+
+// RegisterHook 注册钩子
+// onlyName 为 HookAllType 时对所有工作流类型生效
+func RegisterHook(onlyName, event string, hook Hook) {
+	if _, ok := HookPool[onlyName]; !ok {
+		HookPool[onlyName] = make(map[string][]Hook)
+	}
+
+	HookPool[onlyName][event] = append(HookPool[onlyName][event], hook)
+}
+
+// GetHooks 获取指定工作流类型与事件的钩子，通用钩子在前
+func GetHooks(onlyName, event string) []Hook {
+	hooks := make([]Hook, 0)
+	if events, ok := HookPool[HookAllType]; ok {
+		hooks = append(hooks, events[event]...)
+	}
+	if onlyName != HookAllType {
+		if events, ok := HookPool[onlyName]; ok {
+			hooks = append(hooks, events[event]...)
+		}
+	}
+	return hooks
+}
+
+// callHooks 按注册顺序执行钩子，遇到错误立即返回
+func (engine *Engine) callHooks(events ...string) error {
+	for _, event := range events {
+		for _, hook := range GetHooks(engine.typeData.OnlyName, event) {
+			if err := hook.Handle(engine, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
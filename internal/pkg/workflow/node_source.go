@@ -0,0 +1,70 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/repo"
+	"gorm.io/gorm"
+	"sort"
+)
+
+// NodeSource 工作流节点来源
+// 未发布版本的工作流类型直接读取节点表，已发布的读取版本快照
+type NodeSource interface {
+	GetAppointNode(typeId uint, node int) (*repo.WorkflowNode, error)
+	GetNextNode(typeId uint, currNode int) (*repo.WorkflowNode, error)
+	FirstNode(typeId uint) (*repo.WorkflowNode, error)
+}
+
+// versionNodes 版本快照中的节点，按节点序号升序排列
+type versionNodes []repo.WorkflowNode
+
+// newVersionNodes 从版本快照创建节点来源
+func newVersionNodes(version *repo.WorkflowTypeVersion) versionNodes {
+	nodes := make(versionNodes, len(version.Nodes))
+	copy(nodes, version.Nodes)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+	return nodes
+}
+
+func (nodes versionNodes) GetAppointNode(_ uint, node int) (*repo.WorkflowNode, error) {
+	for i := range nodes {
+		if nodes[i].Node == node {
+			n := nodes[i]
+			return &n, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (nodes versionNodes) GetNextNode(_ uint, currNode int) (*repo.WorkflowNode, error) {
+	for i := range nodes {
+		if nodes[i].Node > currNode {
+			n := nodes[i]
+			return &n, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (nodes versionNodes) FirstNode(typeId uint) (*repo.WorkflowNode, error) {
+	return nodes.GetNextNode(typeId, 0)
+}
+
+// loadVersion 读取工作流类型的指定版本，返回该版本的类型数据与节点来源
+func loadVersion(tx *gorm.DB, typeData *repo.WorkflowType, version int) (*repo.WorkflowType, NodeSource, error) {
+	v, err := data.NewWorkflowTypeVersionRepo(tx, nil).GetVersion(typeData.ID, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versionType := *typeData
+	if v.TypeData != nil {
+		versionType = *v.TypeData
+		// 以下字段以当前类型为准
+		versionType.ID = typeData.ID
+		versionType.ActiveVersion = typeData.ActiveVersion
+	}
+	return &versionType, newVersionNodes(v), nil
+}
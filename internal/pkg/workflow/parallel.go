@@ -0,0 +1,185 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// JoinModeAll 等待所有分支到达
+	JoinModeAll = "all"
+	// JoinModeAny 任意一个分支到达即继续，其余分支取消
+	JoinModeAny = "any"
+)
+
+// JoinModeMap 汇聚方式名称
+var JoinModeMap = map[string]string{
+	JoinModeAll: "等待所有分支",
+	JoinModeAny: "任意一个分支到达",
+}
+
+// parallelFlow 并行分支的流转结果
+type parallelFlow struct {
+	// 流转后的所有分支
+	tokens []repo.WorkflowToken
+	// 需要生成操作人的节点
+	activated []*repo.WorkflowNode
+	// 汇聚完成后工作流所在的节点，未汇聚时为nil
+	joined *repo.WorkflowNode
+}
+
+// ParseFork 解析节点的并行分支，返回各分支第一个节点的序号
+func ParseFork(s string) ([]int, error) {
+	var nodes []int
+	if len(strings.TrimSpace(s)) <= 0 {
+		return nodes, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &nodes); err != nil {
+		return nil, exception.NewException(response.WorkflowNodeParallelInvalid, "并行分支格式错误")
+	}
+	if len(nodes) < 2 {
+		return nil, exception.NewException(response.WorkflowNodeParallelInvalid, "并行分支至少需要两个")
+	}
+	for _, node := range nodes {
+		if node <= 0 {
+			return nil, exception.NewException(response.WorkflowNodeParallelInvalid, "并行分支的节点序号必须大于0")
+		}
+	}
+	return nodes, nil
+}
+
+// CheckParallel 校验节点的并行设置
+func CheckParallel(fork string, joinMode string) error {
+	if _, err := ParseFork(fork); err != nil {
+		return err
+	}
+	if joinMode == "" {
+		return nil
+	}
+	if _, ok := JoinModeMap[joinMode]; !ok {
+		return exception.NewException(response.WorkflowNodeParallelInvalid, "汇聚方式不存在")
+	}
+	return nil
+}
+
+// InParallel 工作流是否处于并行分支中
+func (engine *Engine) InParallel() bool {
+	return len(engine.tokens) > 0
+}
+
+// focusToken 将引擎的当前节点与操作人切换到指定的分支
+func (engine *Engine) focusToken(i int) error {
+	token := &engine.tokens[i]
+	nodeInfo, err := engine.nodes.GetAppointNode(engine.typeId, token.Node)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+	}
+	operators, err := engine.Repo.workflowOperatorRepo.GetWorkflowOperatorByNode(engine.workflowId, token.Node)
+	if err != nil {
+		return exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	engine.token = token
+	engine.nodeInfo = nodeInfo
+	engine.operator = operators
+	return nil
+}
+
+// focusBranch 切换到当前用户要审批的分支
+// node 大于0时使用该节点所在的分支，否则使用当前用户有未处理记录的第一个分支
+func (engine *Engine) focusBranch(node int, userId uint64) error {
+	for i, token := range engine.tokens {
+		if token.Waiting == 1 || (node > 0 && token.Node != node) {
+			continue
+		}
+		if err := engine.focusToken(i); err != nil {
+			return err
+		}
+		if node > 0 || engine.IsOperator(userId) {
+			return nil
+		}
+	}
+	return exception.NewException(response.WorkflowEngineNotOperator)
+}
+
+// forkNodes 节点的所有并行分支的第一个节点
+func (engine *Engine) forkNodes(node *repo.WorkflowNode) ([]*repo.WorkflowNode, error) {
+	forks, err := ParseFork(node.Fork)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*repo.WorkflowNode, 0, len(forks))
+	for _, fork := range forks {
+		n, err := engine.nodes.GetAppointNode(engine.typeId, fork)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// flowParallel 当前分支(分流时为当前节点)通过后，进入 targets 中的节点
+// targets 中的nil表示分支结束，到达汇聚节点的分支等待其他分支，满足汇聚条件后所有分支合并
+func (engine *Engine) flowParallel(targets []*repo.WorkflowNode) (*parallelFlow, error) {
+	flow := &parallelFlow{
+		tokens: make([]repo.WorkflowToken, 0, len(engine.tokens)+len(targets)),
+	}
+	// 保留其他分支
+	for _, token := range engine.tokens {
+		if engine.token != nil && token.ID == engine.token.ID {
+			continue
+		}
+		flow.tokens = append(flow.tokens, token)
+	}
+
+	for _, target := range targets {
+		if target == nil {
+			// 分支结束
+			continue
+		}
+		if target.JoinMode != "" {
+			flow.tokens = append(flow.tokens, repo.WorkflowToken{Node: target.Node, Waiting: 1})
+			continue
+		}
+		flow.tokens = append(flow.tokens, repo.WorkflowToken{Node: target.Node})
+		flow.activated = append(flow.activated, target)
+	}
+
+	// 检查汇聚条件
+	for _, token := range flow.tokens {
+		if token.Waiting != 1 {
+			continue
+		}
+		join, err := engine.nodes.GetAppointNode(engine.typeId, token.Node)
+		if err != nil {
+			return nil, db.FirstQueryErrorHandle(err, response.WorkflowNodeNotExist)
+		}
+		if join.JoinMode == JoinModeAll && !allWaitingAt(flow.tokens, join.Node) {
+			continue
+		}
+
+		// 汇聚完成，未到达的分支取消
+		flow.joined = join
+		flow.tokens = nil
+		flow.activated = []*repo.WorkflowNode{join}
+		break
+	}
+	return flow, nil
+}
+
+// allWaitingAt 所有分支是否都已到达指定的汇聚节点
+func allWaitingAt(tokens []repo.WorkflowToken, node int) bool {
+	for _, token := range tokens {
+		if token.Waiting != 1 || token.Node != node {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/repo"
+	"testing"
+)
+
+// 节点1分流到2、3，4为等待所有分支的汇聚节点，5为任意一个分支到达的汇聚节点
+var parallelNodes = versionNodes{
+	{Node: 1, Fork: "[2,3]"},
+	{Node: 2},
+	{Node: 3},
+	{Node: 4, JoinMode: JoinModeAll},
+	{Node: 5, JoinMode: JoinModeAny},
+	{Node: 6},
+}
+
+func parallelNode(node int) *repo.WorkflowNode {
+	n, _ := parallelNodes.GetAppointNode(0, node)
+	return n
+}
+
+func testToken(id uint, node int, waiting int8) repo.WorkflowToken {
+	t := repo.WorkflowToken{Node: node, Waiting: waiting}
+	t.ID = id
+	return t
+}
+
+func TestFlowParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  []repo.WorkflowToken
+		current int // 当前分支在 tokens 中的下标，-1表示分流
+		targets []*repo.WorkflowNode
+		// 流转后各分支所在的节点与是否等待
+		wantTokens    []repo.WorkflowToken
+		wantActivated []int
+		wantJoined    int
+	}{
+		{
+			name:          "分流",
+			current:       -1,
+			targets:       []*repo.WorkflowNode{parallelNode(2), parallelNode(3)},
+			wantTokens:    []repo.WorkflowToken{testToken(0, 2, 0), testToken(0, 3, 0)},
+			wantActivated: []int{2, 3},
+		},
+		{
+			name:          "分支内顺序流转",
+			tokens:        []repo.WorkflowToken{testToken(1, 2, 0), testToken(2, 3, 0)},
+			current:       0,
+			targets:       []*repo.WorkflowNode{parallelNode(6)},
+			wantTokens:    []repo.WorkflowToken{testToken(2, 3, 0), testToken(0, 6, 0)},
+			wantActivated: []int{6},
+		},
+		{
+			name:       "等待所有分支时第一个到达",
+			tokens:     []repo.WorkflowToken{testToken(1, 2, 0), testToken(2, 3, 0)},
+			current:    0,
+			targets:    []*repo.WorkflowNode{parallelNode(4)},
+			wantTokens: []repo.WorkflowToken{testToken(2, 3, 0), testToken(0, 4, 1)},
+		},
+		{
+			name:          "等待所有分支时最后一个到达",
+			tokens:        []repo.WorkflowToken{testToken(1, 4, 1), testToken(2, 3, 0)},
+			current:       1,
+			targets:       []*repo.WorkflowNode{parallelNode(4)},
+			wantActivated: []int{4},
+			wantJoined:    4,
+		},
+		{
+			name:          "任意一个分支到达时取消其余分支",
+			tokens:        []repo.WorkflowToken{testToken(1, 2, 0), testToken(2, 3, 0)},
+			current:       0,
+			targets:       []*repo.WorkflowNode{parallelNode(5)},
+			wantActivated: []int{5},
+			wantJoined:    5,
+		},
+		{
+			name:          "分支结束后其余分支都已到达汇聚节点",
+			tokens:        []repo.WorkflowToken{testToken(1, 4, 1), testToken(2, 3, 0)},
+			current:       1,
+			targets:       []*repo.WorkflowNode{nil},
+			wantActivated: []int{4},
+			wantJoined:    4,
+		},
+		{
+			name:       "分支结束后仍有进行中的分支",
+			tokens:     []repo.WorkflowToken{testToken(1, 2, 0), testToken(2, 3, 0)},
+			current:    0,
+			targets:    []*repo.WorkflowNode{nil},
+			wantTokens: []repo.WorkflowToken{testToken(2, 3, 0)},
+		},
+		{
+			name:    "所有分支都已结束",
+			tokens:  []repo.WorkflowToken{testToken(1, 2, 0)},
+			current: 0,
+			targets: []*repo.WorkflowNode{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &Engine{nodes: parallelNodes, tokens: tt.tokens}
+			if tt.current >= 0 {
+				engine.token = &engine.tokens[tt.current]
+			}
+
+			flow, err := engine.flowParallel(tt.targets)
+			if err != nil {
+				t.Fatalf("flowParallel() error = %v", err)
+			}
+
+			if len(flow.tokens) != len(tt.wantTokens) {
+				t.Fatalf("tokens = %+v, want %+v", flow.tokens, tt.wantTokens)
+			}
+			for i, want := range tt.wantTokens {
+				got := flow.tokens[i]
+				if got.ID != want.ID || got.Node != want.Node || got.Waiting != want.Waiting {
+					t.Errorf("tokens[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+
+			activated := make([]int, 0, len(flow.activated))
+			for _, node := range flow.activated {
+				activated = append(activated, node.Node)
+			}
+			if len(activated) != len(tt.wantActivated) {
+				t.Fatalf("activated = %v, want %v", activated, tt.wantActivated)
+			}
+			for i := range activated {
+				if activated[i] != tt.wantActivated[i] {
+					t.Errorf("activated = %v, want %v", activated, tt.wantActivated)
+					break
+				}
+			}
+
+			joined := 0
+			if flow.joined != nil {
+				joined = flow.joined.Node
+			}
+			if joined != tt.wantJoined {
+				t.Errorf("joined = %d, want %d", joined, tt.wantJoined)
+			}
+		})
+	}
+}
+
+func TestAllWaitingAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []repo.WorkflowToken
+		want   bool
+	}{
+		{"没有分支", nil, true},
+		{"都已到达", []repo.WorkflowToken{testToken(1, 4, 1), testToken(2, 4, 1)}, true},
+		{"有进行中的分支", []repo.WorkflowToken{testToken(1, 4, 1), testToken(2, 3, 0)}, false},
+		{"等待在其它汇聚节点", []repo.WorkflowToken{testToken(1, 4, 1), testToken(2, 5, 1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allWaitingAt(tt.tokens, 4); got != tt.want {
+				t.Errorf("allWaitingAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/golang-module/carbon/v2"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SignTypeBefore 前加签，加签人提交意见后，加签发起人再审批
+	SignTypeBefore = "before"
+	// SignTypeAfter 后加签，加签发起人同意后，还需要加签人同意节点才通过
+	SignTypeAfter = "after"
+)
+
+// SignTypeMap 加签方式名称
+var SignTypeMap = map[string]string{
+	SignTypeBefore: "前加签",
+	SignTypeAfter:  "后加签",
+}
+
+// unhandledOperator 用户在当前节点未处理的操作人记录
+func (engine *Engine) unhandledOperator(userId uint64) *repo.WorkflowOperator {
+	for i := range engine.operator {
+		if engine.operator[i].UserId == userId && engine.operator[i].Handled == 0 {
+			return &engine.operator[i]
+		}
+	}
+	return nil
+}
+
+// pendingSigners 是否还有未处理的加签人
+// signBy 大于0时只检查该用户发起的加签，except 为不检查的用户
+func (engine *Engine) pendingSigners(signType string, signBy uint64, except uint64) bool {
+	for _, operator := range engine.operator {
+		if operator.SignType != signType || operator.Handled == 1 || operator.UserId == except {
+			continue
+		}
+		if signBy > 0 && operator.SignBy != signBy {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// addSigner 加签，在当前节点加入新的操作人
+func (engine *Engine) addSigner(user *repo.User) error {
+	if engine.nodeInfo.AllowAddSigner != 1 {
+		return exception.NewException(response.WorkflowEngineSignerDenied)
+	}
+
+	current := engine.unhandledOperator(user.ID)
+	if current == nil {
+		return exception.NewException(response.WorkflowEngineNotOperator)
+	}
+	if current.SignType == SignTypeBefore {
+		return exception.NewException(response.WorkflowEngineSignerDenied, "前加签人不能再加签")
+	}
+
+	signType, _ := engine.formData["sign_type"].(string)
+	if _, ok := SignTypeMap[signType]; !ok {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "加签方式不存在")
+	}
+
+	signerIds, _ := engine.formData["signers"].([]uint64)
+	signerIds = slice.Unique(signerIds)
+	if len(signerIds) <= 0 {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "请选择加签人")
+	}
+	for _, operator := range engine.operator {
+		if slice.Contain(signerIds, operator.UserId) {
+			return exception.NewException(response.WorkflowEngineSignerInvalid, fmt.Sprintf("%s已经是当前节点的操作人", operator.Nickname))
+		}
+	}
+
+	signers, err := data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers(signerIds)
+	if err != nil {
+		return exception.ErrorHandle(err, response.DbQueryError)
+	}
+	if len(signers) != len(signerIds) {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "加签人不存在或已禁用")
+	}
+
+	users := make([]string, 0, len(signers))
+	names := make([]string, 0, len(signers))
+	for _, signer := range signers {
+		users = append(users, strconv.FormatUint(signer.ID, 10))
+		names = append(names, signer.UserNickname)
+	}
+
+	err = engine.transaction(func() error {
+		for _, signer := range signers {
+			err := engine.createOperator(repo.WorkflowOperator{
+				UserId:   signer.ID,
+				Nickname: signer.UserNickname,
+				SignType: signType,
+				SignBy:   user.ID,
+			}, engine.nodeInfo)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 后加签时加签发起人同时同意
+		if signType == SignTypeAfter {
+			err := engine.Repo.workflowOperatorRepo.SetVote(engine.workflowId, engine.nodeInfo.Node, user.ID, VoteApprove, carbon.Now().TimestampMilli())
+			if err != nil {
+				return exception.NewException(response.WorkflowEngineOperatorHandleFail)
+			}
+		}
+
+		return engine.writeLog(LogActionAddSigner, engine.nodeInfo, engine.nodeInfo, user,
+			fmt.Sprintf("%s给%s", SignTypeMap[signType], strings.Join(names, "、")))
+	})
+	if err != nil {
+		return err
+	}
+
+	engine.notify(users, LogActionAddSigner, fmt.Sprintf("%s邀请您审批工作流", user.UserNickname))
+	return nil
+}
+
+// transfer 转交，将自己在当前节点的审批交给其他人处理
+func (engine *Engine) transfer(user *repo.User) error {
+	if engine.nodeInfo.AllowTransfer != 1 {
+		return exception.NewException(response.WorkflowEngineTransferDenied)
+	}
+
+	current := engine.unhandledOperator(user.ID)
+	if current == nil {
+		return exception.NewException(response.WorkflowEngineNotOperator)
+	}
+
+	targetId, _ := engine.formData["transfer_to"].(uint64)
+	if targetId <= 0 {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "请选择转交的用户")
+	}
+	if targetId == user.ID {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "不能转交给自己")
+	}
+	for _, operator := range engine.operator {
+		if operator.UserId == targetId && operator.Handled == 0 {
+			return exception.NewException(response.WorkflowEngineSignerInvalid, fmt.Sprintf("%s已经是当前节点的操作人", operator.Nickname))
+		}
+	}
+
+	targets, err := data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers([]uint64{targetId})
+	if err != nil {
+		return exception.ErrorHandle(err, response.DbQueryError)
+	}
+	if len(targets) <= 0 {
+		return exception.NewException(response.WorkflowEngineSignerInvalid, "转交的用户不存在或已禁用")
+	}
+	target := targets[0]
+
+	err = engine.transaction(func() error {
+		// 记录日志时需要原操作人的代理信息，所以先记录日志
+		err := engine.writeLog(LogActionTransfer, engine.nodeInfo, engine.nodeInfo, user,
+			fmt.Sprintf("转交给%s", target.UserNickname))
+		if err != nil {
+			return err
+		}
+
+		err = engine.Repo.workflowOperatorRepo.UpdateFields(current.ID, map[string]interface{}{
+			"user_id":           target.ID,
+			"nickname":          target.UserNickname,
+			"original_user_id":  0,
+			"original_nickname": "",
+		})
+		return exception.ErrorHandle(err, response.WorkflowEngineOperatorHandleFail)
+	})
+	if err != nil {
+		return err
+	}
+
+	engine.notify([]string{strconv.FormatUint(target.ID, 10)}, LogActionTransfer,
+		fmt.Sprintf("%s将工作流审批转交给您", user.UserNickname))
+	return nil
+}
+
+// signOpinion 前加签人提交意见，不改变工作流状态
+func (engine *Engine) signOpinion(user *repo.User, operator *repo.WorkflowOperator, reject bool) error {
+	vote, opinion := VoteApprove, "同意"
+	if reject {
+		vote, opinion = VoteReject, "反对"
+	}
+
+	err := engine.transaction(func() error {
+		err := engine.Repo.workflowOperatorRepo.SetVote(engine.workflowId, engine.nodeInfo.Node, user.ID, vote, carbon.Now().TimestampMilli())
+		if err != nil {
+			return exception.NewException(response.WorkflowEngineOperatorHandleFail)
+		}
+
+		// 尝试写入工作流附加数据
+		if engine.dataChanged {
+			if err := engine.saveData(engine.workflowId, engine.nodeInfo.Node, user); err != nil {
+				return err
+			}
+		}
+
+		return engine.writeLog(LogActionSignOpinion, engine.nodeInfo, engine.nodeInfo, user, "加签意见："+opinion)
+	})
+	if err != nil {
+		return err
+	}
+
+	engine.notify([]string{strconv.FormatUint(operator.SignBy, 10)}, LogActionSignOpinion,
+		fmt.Sprintf("%s已提交加签意见：%s", user.UserNickname, opinion))
+	return nil
+}
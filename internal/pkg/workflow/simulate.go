@@ -0,0 +1,242 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"errors"
+	"fmt"
+)
+
+// simulateMaxSteps 模拟流转的最大步数，防止条件分支形成循环
+const simulateMaxSteps = 100
+
+// errSimulateRollback 模拟结束后回滚事务
+var errSimulateRollback = errors.New("simulate rollback")
+
+// SimulateStep 模拟流转经过的节点
+type SimulateStep struct {
+	Node   int    `json:"node"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	// 所在的并行分支，从1开始，不在并行分支中时为0
+	Branch int `json:"branch,omitempty"`
+	// 并行分支的第一个节点
+	Fork []int `json:"fork,omitempty"`
+	// 汇聚方式
+	JoinMode string `json:"join_mode,omitempty"`
+	// 节点的操作人
+	Operators []repo.WorkflowOperator `json:"operators"`
+	// 节点的抄送人
+	Cc []repo.User `json:"cc,omitempty"`
+	// 获取操作人时的错误
+	Error string `json:"error,omitempty"`
+}
+
+// SimulateResult 模拟流转的结果
+type SimulateResult struct {
+	Steps []SimulateStep `json:"steps"`
+	// 是否能流转到结束
+	Completed bool `json:"completed"`
+	// 表单校验或流转中的错误
+	Errors []string `json:"errors"`
+}
+
+// UseVersion 切换引擎使用的定义版本，只用于模拟
+// version 为0时使用节点表中正在编辑的节点，否则使用已发布的版本快照
+func (engine *Engine) UseVersion(version int) error {
+	typeData, err := engine.Repo.workflowTypeRepo.Get(engine.typeId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.WorkflowTypeNotExist)
+	}
+	if version <= 0 {
+		engine.typeData = typeData
+		engine.nodes = engine.Repo.workflowNodeRepo
+		return nil
+	}
+
+	typeData, nodes, err := loadVersion(engine.Orm, typeData, version)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.WorkflowTypeVersionNotExist)
+	}
+	engine.typeData = typeData
+	engine.nodes = nodes
+	return nil
+}
+
+// Simulate 模拟工作流的流转，返回经过的节点与每个节点的操作人
+// 以当前操作人作为发起人，假设每个节点都审批通过。所有查询在事务中执行并回滚，不会写入任何数据
+// 模拟后引擎不能再用于发起工作流
+func (engine *Engine) Simulate() (*SimulateResult, error) {
+	// 检查是否初始化
+	if !engine.initialized {
+		return nil, exception.NewException(response.WorkflowEngineNotInitialized)
+	}
+
+	user, err := engine.GetActor()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SimulateResult{
+		Steps:  make([]SimulateStep, 0),
+		Errors: make([]string, 0),
+	}
+	if err := engine.ValidateData(); err != nil {
+		result.addError(err)
+	}
+
+	// 未保存的工作流，节点动作从中读取发起人等信息
+	engine.workflow = &repo.Workflow{
+		TypeId:   engine.typeId,
+		TypeName: engine.typeData.Name,
+		Promoter: user.ID,
+		Nickname: user.UserNickname,
+		Status:   StatusRunning,
+		Version:  engine.typeData.ActiveVersion,
+		// 关联的项目与任务
+		ProjectId: engine.projectId,
+		TaskId:    engine.taskId,
+	}
+
+	err = engine.transaction(func() error {
+		engine.simulate(result)
+		return errSimulateRollback
+	})
+	if err != nil && !errors.Is(err, errSimulateRollback) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// simulate 从第一个节点开始依次流转
+func (engine *Engine) simulate(result *SimulateResult) {
+	// 第一个节点由发起人提交
+	first, err := engine.nodes.FirstNode(engine.typeId)
+	if err != nil {
+		result.addError(db.FirstQueryErrorHandle(err, response.WorkflowEngineNoFirstNodeSet))
+		return
+	}
+	result.Steps = append(result.Steps, SimulateStep{
+		Node:   first.Node,
+		Name:   first.Name,
+		Action: first.Action,
+		Operators: []repo.WorkflowOperator{{
+			UserId:   engine.workflow.Promoter,
+			Nickname: engine.workflow.Nickname,
+			Node:     first.Node,
+		}},
+	})
+
+	for len(result.Steps) < simulateMaxSteps {
+		node, err := engine.NextNode()
+		if err != nil {
+			result.addError(err)
+			return
+		}
+		if node == nil {
+			result.Completed = true
+			return
+		}
+		engine.workflow.Node = node.Node
+		engine.nodeInfo = node
+
+		step := engine.simulateStep(node, 0)
+		result.Steps = append(result.Steps, step)
+		if len(step.Fork) <= 0 {
+			continue
+		}
+
+		// 并行分支依次流转到汇聚节点
+		join, err := engine.simulateFork(result, node)
+		if err != nil {
+			result.addError(err)
+			return
+		}
+		if join == nil {
+			result.Completed = true
+			return
+		}
+		engine.workflow.Node = join.Node
+		engine.nodeInfo = join
+		result.Steps = append(result.Steps, engine.simulateStep(join, 0))
+	}
+	result.Errors = append(result.Errors, fmt.Sprintf("流转超过%d步，请检查条件分支是否形成循环", simulateMaxSteps))
+}
+
+// simulateFork 依次模拟节点的每个并行分支，返回分支汇聚的节点，所有分支都直接结束时返回nil
+func (engine *Engine) simulateFork(result *SimulateResult, node *repo.WorkflowNode) (*repo.WorkflowNode, error) {
+	forks, err := engine.forkNodes(node)
+	if err != nil {
+		return nil, err
+	}
+
+	// 以分支所在节点为当前节点计算下一个节点
+	engine.token = &repo.WorkflowToken{}
+	defer func() {
+		engine.token = nil
+	}()
+
+	var join *repo.WorkflowNode
+	for i, curr := range forks {
+		for curr != nil && curr.JoinMode == "" {
+			if len(result.Steps) >= simulateMaxSteps {
+				return nil, exception.NewException(response.WorkflowNodeParallelInvalid, "并行分支流转步数过多")
+			}
+			if curr.Fork != "" {
+				return nil, exception.NewException(response.WorkflowNodeParallelInvalid, "不支持在并行分支中再次分流")
+			}
+			result.Steps = append(result.Steps, engine.simulateStep(curr, i+1))
+
+			engine.nodeInfo = curr
+			curr, err = engine.NextNode()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if join == nil {
+			join = curr
+		}
+	}
+	return join, nil
+}
+
+// simulateStep 解析节点的操作人
+func (engine *Engine) simulateStep(node *repo.WorkflowNode, branch int) SimulateStep {
+	step := SimulateStep{
+		Node:      node.Node,
+		Name:      node.Name,
+		Action:    node.Action,
+		Branch:    branch,
+		JoinMode:  node.JoinMode,
+		Operators: make([]repo.WorkflowOperator, 0),
+	}
+	step.Fork, _ = ParseFork(node.Fork)
+
+	operators, err := engine.GetOperator(node)
+	if err != nil {
+		step.Error = errorMessage(err)
+		return step
+	}
+	for _, operator := range operators {
+		operator.Node = node.Node
+		step.Operators = append(step.Operators, operator)
+	}
+
+	step.Cc, err = engine.ccUsers(node)
+	if err != nil {
+		step.Error = errorMessage(err)
+	}
+	return step
+}
+
+// addError 记录错误
+func (result *SimulateResult) addError(err error) {
+	result.Errors = append(result.Errors, errorMessage(err))
+}
+
+// errorMessage 错误信息，自定义异常没有消息时使用状态码对应的消息
+func errorMessage(err error) string {
+	return response.Error(err).Message
+}
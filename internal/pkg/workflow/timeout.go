@@ -0,0 +1,316 @@
+package workflow
+
+import (
+	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/pkg/im"
+	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-module/carbon/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemUser 系统执行超时动作时记录的操作人
+var systemUser = &repo.User{UserNickname: "系统"}
+
+// ParseUserIds 解析json数组格式的用户ID
+func ParseUserIds(s string) ([]uint64, error) {
+	var ids []uint64
+	if len(strings.TrimSpace(s)) <= 0 {
+		return ids, nil
+	}
+
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CheckTimeoutSetting 校验节点的超时设置
+func CheckTimeoutSetting(timeout int, action string, fallbackUsers string) error {
+	if timeout < 0 {
+		return exception.NewException(response.WorkflowNodeTimeoutInvalid, "超时时间不能小于0")
+	}
+	if timeout == 0 {
+		return nil
+	}
+
+	if _, ok := TimeoutActionMap[action]; !ok {
+		return exception.NewException(response.WorkflowNodeTimeoutInvalid, "超时动作不存在")
+	}
+
+	ids, err := ParseUserIds(fallbackUsers)
+	if err != nil {
+		return exception.NewException(response.WorkflowNodeTimeoutInvalid, "备用审批人格式错误")
+	}
+	if action == TimeoutActionEscalate && len(ids) <= 0 {
+		return exception.NewException(response.WorkflowNodeTimeoutInvalid, "超时转交必须设置备用审批人")
+	}
+	return nil
+}
+
+// RunTimeoutScheduler 定时扫描审批超时的工作流，会阻塞当前协程
+// interval 小于等于0时直接返回
+func RunTimeoutScheduler(tx *gorm.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		CheckTimeout(tx)
+	}
+}
+
+// CheckTimeout 扫描审批超时的工作流，并执行节点配置的超时动作
+// 每个工作流在锁定后重新打开，多个实例同时扫描时同一次超时只处理一次
+func CheckTimeout(tx *gorm.DB) {
+	defer func() {
+		if err := recover(); err != nil {
+			logrus.Errorln("工作流超时扫描异常:", err)
+		}
+	}()
+
+	now := carbon.Now().TimestampMilli()
+	ids, err := data.NewWorkflowOperatorRepo(tx, nil).GetTimeoutWorkflowIds(StatusRunning, now)
+	if err != nil {
+		logrus.Errorln("工作流超时扫描失败:", err)
+		return
+	}
+
+	for _, id := range ids {
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			if err := data.NewWorkflowRepo(tx, nil).Lock(id); err != nil {
+				return err
+			}
+
+			// 在锁内读取操作人，已处理或截止时间已变化的操作人不再超时
+			// 没有操作人，由引擎在需要时设置
+			engine, err := Open(tx, context.Background(), nil, id)
+			if err != nil {
+				return err
+			}
+			return engine.HandleTimeout(now)
+		})
+		if err != nil {
+			logrus.Errorf("工作流[%d]超时处理失败: %v", id, err)
+		}
+	}
+}
+
+// HandleTimeout 对当前节点或进行中的并行分支中审批超时的操作人执行超时动作
+func (engine *Engine) HandleTimeout(now int64) error {
+	// 检查是否初始化
+	if !engine.initialized {
+		return exception.NewException(response.WorkflowEngineNotInitialized)
+	}
+
+	if engine.workflow.Status != StatusRunning {
+		return nil
+	}
+	if !engine.InParallel() {
+		_, err := engine.nodeTimeout(now)
+		return err
+	}
+
+	// 并行审批时逐个检查进行中的分支
+	for i, token := range engine.tokens {
+		if token.Waiting == 1 {
+			continue
+		}
+		if err := engine.focusToken(i); err != nil {
+			return err
+		}
+		approved, err := engine.nodeTimeout(now)
+		if err != nil || approved {
+			// 自动同意后分支已改变，剩余的分支在下次扫描时处理
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeTimeout 对当前节点审批超时的操作人执行超时动作，执行了自动同意时返回true
+func (engine *Engine) nodeTimeout(now int64) (bool, error) {
+	if engine.nodeInfo == nil || engine.nodeInfo.Timeout <= 0 {
+		return false, nil
+	}
+
+	expired := make([]repo.WorkflowOperator, 0)
+	for _, operator := range engine.operator {
+		if operator.Handled == 1 || operator.Deadline <= 0 {
+			continue
+		}
+		if operator.Deadline <= now {
+			expired = append(expired, operator)
+		}
+	}
+	if len(expired) <= 0 {
+		return false, nil
+	}
+
+	switch engine.nodeInfo.TimeoutAction {
+	case TimeoutActionApprove:
+		return true, engine.timeoutApprove(expired[0])
+	case TimeoutActionEscalate:
+		return false, engine.timeoutEscalate(expired, now)
+	default:
+		return false, engine.timeoutRemind(expired, now)
+	}
+}
+
+// timeoutApprove 由系统代替超时的操作人自动同意，日志记录为系统代该操作人处理
+// 一次只处理一个操作人，其余的在下次扫描时处理
+func (engine *Engine) timeoutApprove(operator repo.WorkflowOperator) error {
+	user, err := data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUser(operator.UserId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.UserNotFound)
+	}
+	engine.SetActor(user)
+	engine.bySystem = true
+	defer func() {
+		engine.bySystem = false
+	}()
+	engine.SetFormData(map[string]interface{}{
+		"action":  LogActionNext,
+		"node":    engine.nodeInfo.Node,
+		"comment": "审批超时，系统自动同意",
+	})
+	return engine.ExamineApprove()
+}
+
+// timeoutRemind 提醒超时的操作人
+func (engine *Engine) timeoutRemind(expired []repo.WorkflowOperator, now int64) error {
+	ids := make([]uint, 0, len(expired))
+	users := make([]string, 0, len(expired))
+	names := make([]string, 0, len(expired))
+	for _, operator := range expired {
+		ids = append(ids, operator.ID)
+		users = append(users, strconv.FormatUint(operator.UserId, 10))
+		names = append(names, operator.Nickname)
+	}
+
+	// 提醒每超过一个周期重复一次
+	deadline := now + int64(engine.nodeInfo.Timeout)*60*1000
+	err := engine.timeoutTransaction(ids, now, deadline, func() error {
+		return engine.writeLog(LogActionRemind, engine.nodeInfo, engine.nodeInfo, systemUser,
+			fmt.Sprintf("审批超时，已提醒%s", strings.Join(names, "、")))
+	})
+	if err != nil {
+		return err
+	}
+
+	engine.notify(users, LogActionRemind, "您有一个工作流审批已超时，请尽快处理")
+	return nil
+}
+
+// timeoutEscalate 将超时的审批转交给节点的备用审批人
+// 原操作人仍可以继续审批
+func (engine *Engine) timeoutEscalate(expired []repo.WorkflowOperator, now int64) error {
+	fallbackIds, err := ParseUserIds(engine.nodeInfo.FallbackUsers)
+	if err != nil {
+		return exception.NewException(response.WorkflowNodeTimeoutInvalid, "备用审批人格式错误")
+	}
+	// 没有备用审批人时退化为提醒
+	if len(fallbackIds) <= 0 {
+		return engine.timeoutRemind(expired, now)
+	}
+
+	userRepo := data.NewUserRepo(engine.GetCorrectOrm(), nil)
+	fallbackUsers := make([]repo.User, 0, len(fallbackIds))
+	for _, id := range fallbackIds {
+		u, err := userRepo.GetUser(id)
+		if err != nil {
+			return err
+		}
+		fallbackUsers = append(fallbackUsers, *u)
+	}
+	operators, err := engine.delegate(fallbackUsers)
+	if err != nil {
+		return err
+	}
+
+	// 跳过已经是该节点操作人的用户
+	exists := make(map[uint64]bool, len(engine.operator))
+	for _, operator := range engine.operator {
+		exists[operator.UserId] = true
+	}
+	newOperators := make([]repo.WorkflowOperator, 0, len(operators))
+	users := make([]string, 0, len(operators))
+	names := make([]string, 0, len(operators))
+	for _, operator := range operators {
+		if exists[operator.UserId] {
+			continue
+		}
+		operator.Node = engine.nodeInfo.Node
+		operator.WorkflowId = engine.workflowId
+		newOperators = append(newOperators, operator)
+		users = append(users, strconv.FormatUint(operator.UserId, 10))
+		names = append(names, operator.Nickname)
+	}
+
+	ids := make([]uint, 0, len(expired))
+	for _, operator := range expired {
+		ids = append(ids, operator.ID)
+	}
+
+	// 转交只执行一次
+	err = engine.timeoutTransaction(ids, now, 0, func() error {
+		for _, wo := range newOperators {
+			if err := engine.Repo.workflowOperatorRepo.Create(&wo); err != nil {
+				return exception.NewException(response.WorkflowEngineSaveOperatorFail)
+			}
+		}
+
+		message := "审批超时，备用审批人已是当前操作人"
+		if len(names) > 0 {
+			message = fmt.Sprintf("审批超时，已转交给%s", strings.Join(names, "、"))
+		}
+		return engine.writeLog(LogActionEscalate, engine.nodeInfo, engine.nodeInfo, systemUser, message)
+	})
+	if err != nil {
+		return err
+	}
+
+	engine.notify(users, LogActionEscalate, "有一个超时的工作流转交给您审批，请尽快处理")
+	return nil
+}
+
+// timeoutTransaction 在事务中记录超时动作时间与新的截止时间并执行fn
+func (engine *Engine) timeoutTransaction(ids []uint, now int64, deadline int64, fn func() error) error {
+	return engine.transaction(func() error {
+		err := engine.Repo.workflowOperatorRepo.SetTimeout(ids, now, deadline)
+		if err != nil {
+			return exception.ErrorHandle(err, response.DbExecuteError)
+		}
+
+		return fn()
+	})
+}
+
+// notify 通过网关通知用户，失败只记录日志
+func (engine *Engine) notify(users []string, event string, message string) {
+	if len(users) <= 0 {
+		return
+	}
+
+	err := im.SendUsers(users, map[string]interface{}{
+		"type":        "workflow",
+		"event":       event,
+		"workflow_id": engine.workflowId,
+		"title":       engine.workflow.Title,
+		"message":     message,
+	})
+	if err != nil {
+		logrus.Errorf("工作流[%d]通知发送失败: %v", engine.workflowId, err)
+	}
+}
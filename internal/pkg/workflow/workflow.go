@@ -2,7 +2,16 @@ package workflow
 
 import (
 	"VitaTaskGo/internal/api/data"
+	"VitaTaskGo/internal/pkg/constant"
 	"VitaTaskGo/internal/repo"
+	"VitaTaskGo/pkg/db"
+	"VitaTaskGo/pkg/exception"
+	"VitaTaskGo/pkg/response"
+	"encoding/json"
+	"fmt"
+	"github.com/duke-git/lancet/v2/slice"
+	"gorm.io/gorm"
+	"strings"
 )
 
 type AdministratorNodeAction struct {
@@ -15,17 +24,17 @@ func (r *AdministratorNodeAction) ActionName() string {
 	return "管理员操作"
 }
 
-func (r *AdministratorNodeAction) Handle(engine *Engine) ([]repo.User, error) {
-	return data.NewUserRepo(engine.GetCorrectOrm(), engine.ctx).GetAdministrators()
+func (r *AdministratorNodeAction) Handle(engine *Engine, _ *repo.WorkflowNode) ([]repo.User, error) {
+	return data.NewUserRepo(engine.GetCorrectOrm(), nil).GetAdministrators()
 }
 
 func (r *InitiatorNodeAction) ActionName() string {
 	return "发起人操作"
 }
 
-func (r *InitiatorNodeAction) Handle(engine *Engine) ([]repo.User, error) {
+func (r *InitiatorNodeAction) Handle(engine *Engine, _ *repo.WorkflowNode) ([]repo.User, error) {
 	// 发起人操作
-	u, err := data.NewUserRepo(engine.GetCorrectOrm(), engine.ctx).GetUser(engine.workflow.Promoter)
+	u, err := data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUser(engine.workflow.Promoter)
 	if err != nil {
 		return nil, err
 	}
@@ -33,10 +42,309 @@ func (r *InitiatorNodeAction) Handle(engine *Engine) ([]repo.User, error) {
 	return []repo.User{*u}, nil
 }
 
+// ActionValue 节点动作的配置，json对象字符串
+// 关联的项目或任务可以直接指定ID，也可以通过 Field 从工作流数据中读取，都没有时使用工作流关联的项目或任务
+type ActionValue struct {
+	ProjectId uint   `json:"project_id,omitempty"`
+	TaskId    uint   `json:"task_id,omitempty"`
+	Field     string `json:"field,omitempty"`
+	Role      int    `json:"role,omitempty"`
+}
+
+// ParseActionValue 解析节点动作的配置
+func ParseActionValue(s string) (*ActionValue, error) {
+	v := &ActionValue{}
+	if len(strings.TrimSpace(s)) <= 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return nil, exception.NewException(response.WorkflowNodeActionValueInvalid)
+	}
+	return v, nil
+}
+
+// relatedId 获取关联的ID，优先使用直接指定的值，其次是工作流数据中的字段，最后是 fallback
+func (engine *Engine) relatedId(id uint, field string, fallback uint) uint {
+	if id > 0 {
+		return id
+	}
+	if value, ok := lookupField(engine.conditionData(), field); ok {
+		if f, ok := toFloat(value); ok && f > 0 {
+			return uint(f)
+		}
+	}
+	return fallback
+}
+
+// ProjectLeaderNodeAction 项目负责人
+// ActionValue: {"project_id": 1} 或 {"field": "project_id"}，默认读取工作流数据的 project_id 字段
+type ProjectLeaderNodeAction struct {
+}
+
+func (r *ProjectLeaderNodeAction) ActionName() string {
+	return "项目负责人"
+}
+
+func (r *ProjectLeaderNodeAction) CheckValue(value string) error {
+	_, err := ParseActionValue(value)
+	return err
+}
+
+func (r *ProjectLeaderNodeAction) Handle(engine *Engine, node *repo.WorkflowNode) ([]repo.User, error) {
+	return projectUsersByRole(engine, node, constant.ProjectLeader)
+}
+
+// ProjectRoleNodeAction 项目中指定角色的成员
+// ActionValue: {"role": 6, "project_id": 1} 或 {"role": 6, "field": "project_id"}，role 为角色位
+type ProjectRoleNodeAction struct {
+}
+
+func (r *ProjectRoleNodeAction) ActionName() string {
+	return "项目角色成员"
+}
+
+func (r *ProjectRoleNodeAction) CheckValue(value string) error {
+	v, err := ParseActionValue(value)
+	if err != nil {
+		return err
+	}
+	if v.Role <= 0 {
+		return exception.NewException(response.WorkflowNodeActionValueInvalid, "缺少项目角色")
+	}
+	return nil
+}
+
+func (r *ProjectRoleNodeAction) Handle(engine *Engine, node *repo.WorkflowNode) ([]repo.User, error) {
+	v, err := ParseActionValue(node.ActionValue)
+	if err != nil {
+		return nil, err
+	}
+	return projectUsersByRole(engine, node, v.Role)
+}
+
+// projectUsersByRole 获取项目中拥有 roleBits 中任意角色的成员
+func projectUsersByRole(engine *Engine, node *repo.WorkflowNode, roleBits int) ([]repo.User, error) {
+	v, err := ParseActionValue(node.ActionValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Field) <= 0 {
+		v.Field = "project_id"
+	}
+
+	projectId := engine.relatedId(v.ProjectId, v.Field, engine.GetProjectId())
+	if projectId <= 0 {
+		return nil, exception.NewException(response.WorkflowFormDataInvalid, "缺少关联的项目")
+	}
+
+	// 角色位拆分为单个角色
+	roles := make([]int, 0)
+	for role := range constant.GetProjectRoles() {
+		if roleBits&role != 0 {
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) <= 0 {
+		return nil, exception.NewException(response.ProjectRoleNonExistent)
+	}
+
+	members, err := data.NewProjectMemberRepo(engine.GetCorrectOrm(), nil).GetMembersByRole(projectId, roles)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.ProjectMemberQueryFail)
+	}
+
+	userIds := make([]uint64, 0, len(members))
+	for _, member := range members {
+		userIds = append(userIds, member.UserId)
+	}
+	return data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers(slice.Unique(userIds))
+}
+
+// TaskLeaderNodeAction 关联任务的负责人
+// ActionValue: {"task_id": 1} 或 {"field": "task_id"}，默认读取工作流数据的 task_id 字段
+type TaskLeaderNodeAction struct {
+}
+
+func (r *TaskLeaderNodeAction) ActionName() string {
+	return "任务负责人"
+}
+
+func (r *TaskLeaderNodeAction) CheckValue(value string) error {
+	_, err := ParseActionValue(value)
+	return err
+}
+
+func (r *TaskLeaderNodeAction) Handle(engine *Engine, node *repo.WorkflowNode) ([]repo.User, error) {
+	v, err := ParseActionValue(node.ActionValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.Field) <= 0 {
+		v.Field = "task_id"
+	}
+
+	taskId := engine.relatedId(v.TaskId, v.Field, engine.GetTaskId())
+	if taskId <= 0 {
+		return nil, exception.NewException(response.WorkflowFormDataInvalid, "缺少关联的任务")
+	}
+
+	members, err := data.NewTaskMemberRepo(engine.GetCorrectOrm(), nil).GetMembersByRole(taskId, []int{constant.TaskLeader})
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	userIds := make([]uint64, 0, len(members))
+	for _, member := range members {
+		userIds = append(userIds, member.UserId)
+	}
+	return data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers(userIds)
+}
+
+// OrgManagerNodeAction 发起人所在组织的负责人
+// 发起人所在组织没有其他负责人时，逐级向上级组织查找
+// ActionValue: {"role": 1}，组织中负责人的角色值，默认为 constant.OrgManager
+type OrgManagerNodeAction struct {
+}
+
+func (r *OrgManagerNodeAction) ActionName() string {
+	return "发起人组织负责人"
+}
+
+func (r *OrgManagerNodeAction) CheckValue(value string) error {
+	_, err := ParseActionValue(value)
+	return err
+}
+
+func (r *OrgManagerNodeAction) Handle(engine *Engine, node *repo.WorkflowNode) ([]repo.User, error) {
+	v, err := ParseActionValue(node.ActionValue)
+	if err != nil {
+		return nil, err
+	}
+	role := constant.OrgManager
+	if v.Role > 0 {
+		role = v.Role
+	}
+
+	promoter := engine.workflow.Promoter
+	orgUserRepo := data.NewOrgUserRepo(engine.GetCorrectOrm(), nil)
+	orgRepo := data.NewOrganizationRepo(engine.GetCorrectOrm(), nil)
+
+	orgIds, err := orgUserRepo.GetUserOrgIds(promoter)
+	if err != nil {
+		return nil, exception.ErrorHandle(err, response.DbQueryError)
+	}
+
+	// 防止组织数据出现环
+	visited := make(map[int]bool)
+	for len(orgIds) > 0 {
+		managers, err := orgUserRepo.GetOrgUsersByRole(orgIds, role)
+		if err != nil {
+			return nil, exception.ErrorHandle(err, response.DbQueryError)
+		}
+
+		userIds := make([]uint64, 0, len(managers))
+		for _, manager := range managers {
+			if manager.Uid != promoter {
+				userIds = append(userIds, manager.Uid)
+			}
+		}
+		if len(userIds) > 0 {
+			return data.NewUserRepo(engine.GetCorrectOrm(), nil).GetUsers(slice.Unique(userIds))
+		}
+
+		// 查找上级组织
+		parentIds := make([]int, 0)
+		for _, orgId := range orgIds {
+			visited[orgId] = true
+			org, err := orgRepo.Get(orgId)
+			if err != nil {
+				continue
+			}
+			if org.ParentId > 0 && !visited[org.ParentId] {
+				parentIds = append(parentIds, org.ParentId)
+			}
+		}
+		orgIds = slice.Unique(parentIds)
+	}
+
+	return []repo.User{}, nil
+}
+
+// TaskStatusHandler 以指定用户的身份修改任务状态，需要与接口修改任务状态使用相同的规则
+// reason 为日志的前缀
+type TaskStatusHandler func(tx *gorm.DB, actor *repo.User, taskId uint, category int, reason string) error
+
+var taskStatusHandler TaskStatusHandler
+
+// RegisterTaskStatusHandler 注册修改任务状态的处理，由业务层注册
+func RegisterTaskStatusHandler(handler TaskStatusHandler) {
+	taskStatusHandler = handler
+}
+
+// TaskAcceptanceHook 任务验收
+// 发起时校验关联的任务，工作流完成后将任务标记为已完成
+// 使用工作流关联的任务，未关联时从工作流附加数据的 task_id 字段获取
+type TaskAcceptanceHook struct {
+}
+
+func (r *TaskAcceptanceHook) HookName() string {
+	return "任务验收"
+}
+
+func (r *TaskAcceptanceHook) Handle(engine *Engine, event string) error {
+	// 兼容旧数据，未关联任务时读取附加数据的 task_id 字段
+	taskId := engine.relatedId(engine.GetTaskId(), "task_id", 0)
+	if taskId <= 0 {
+		return exception.NewException(response.WorkflowFormDataInvalid, "缺少关联的任务")
+	}
+
+	taskRepo := data.NewTaskRepo(engine.GetCorrectOrm(), nil)
+	task, err := taskRepo.Get(taskId)
+	if err != nil {
+		return db.FirstQueryErrorHandle(err, response.TaskNotExist)
+	}
+
+	switch event {
+	case HookBeforeInitiate:
+		// 只有进行中的任务才需要验收
+		if task.Status != constant.TaskCategoryOpen {
+			return exception.NewException(response.TaskStatusNotProcessing, "只有进行中的任务才能发起验收")
+		}
+	case HookAfterComplete:
+		if task.Status != constant.TaskCategoryOpen {
+			return nil
+		}
+
+		user, err := engine.GetActor()
+		if err != nil {
+			return err
+		}
+
+		// 与接口使用相同的规则修改状态，不满足时回滚本次审批
+		if taskStatusHandler == nil {
+			return exception.NewException(response.SystemFail, "未注册任务状态处理")
+		}
+		return taskStatusHandler(engine.GetCorrectOrm(), user, task.ID, constant.TaskCategoryDone,
+			fmt.Sprintf("验收工作流[%s]已完成，", engine.GetWorkflow().Serials))
+	}
+	return nil
+}
+
 // Init 工作流模块初始化
 func Init() {
 	// 注册节点动作-管理员操作
 	RegisterAction("Administrator", &AdministratorNodeAction{})
 	// 注册节点动作-发起人操作
 	RegisterAction("Initiator", &InitiatorNodeAction{})
+	// 注册节点动作-项目负责人
+	RegisterAction("ProjectLeader", &ProjectLeaderNodeAction{})
+	// 注册节点动作-项目角色成员
+	RegisterAction("ProjectRole", &ProjectRoleNodeAction{})
+	// 注册节点动作-任务负责人
+	RegisterAction("TaskLeader", &TaskLeaderNodeAction{})
+	// 注册节点动作-发起人组织负责人
+	RegisterAction("OrgManager", &OrgManagerNodeAction{})
+	// 注册钩子-任务验收
+	RegisterHook("task-acceptance", HookBeforeInitiate, &TaskAcceptanceHook{})
+	RegisterHook("task-acceptance", HookAfterComplete, &TaskAcceptanceHook{})
 }
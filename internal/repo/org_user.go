@@ -10,3 +10,10 @@ type OrgUser struct {
 func (receiver OrgUser) TableName() string {
 	return GetTablePrefix() + "org_user"
 }
+
+type OrgUserRepo interface {
+	// GetUserOrgIds 获取用户所属的组织ID
+	GetUserOrgIds(uid uint64) ([]int, error)
+	// GetOrgUsersByRole 获取组织中指定角色的用户
+	GetOrgUsersByRole(orgIds []int, role int) ([]OrgUser, error)
+}
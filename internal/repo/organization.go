@@ -16,3 +16,7 @@ type Organization struct {
 func (receiver Organization) TableName() string {
 	return GetTablePrefix() + "organization"
 }
+
+type OrganizationRepo interface {
+	Get(id int) (*Organization, error)
+}
@@ -7,25 +7,40 @@ import (
 type Task struct {
 	BaseModel
 	DeletedAt
-	ProjectId    uint          `json:"project_id" gorm:"index:project_id"`
-	GroupId      uint          `json:"group_id" gorm:"index:project_id"`
-	Title        string        `json:"title" gorm:"size:256"`
-	Describe     string        `json:"describe,omitempty" gorm:""`
-	Status       uint8         `json:"status" gorm:"index:project_id"`
-	Level        uint          `json:"level" gorm:"index:project_id"`
-	CompleteDate int64         `json:"complete_date" gorm:"default:null"`
-	ArchivedDate int64         `json:"archived_date" gorm:"default:null"`
-	StartDate    int64         `json:"start_date" gorm:"default:null"`
-	EndDate      int64         `json:"end_date" gorm:"default:null"`
-	EnclosureNum uint          `json:"enclosure_num"`
-	DialogId     uint          `json:"dialog_id" gorm:"default:0"`
-	PlanTime     []int64       `json:"plan_time" gorm:"-"`
-	Project      *Project      `json:"project,omitempty"` // 一对多（反向）
-	Member       []*TaskMember `json:"member,omitempty" gorm:"foreignKey:TaskId"`
-	Leader       *TaskMember   `json:"leader,omitempty" gorm:"-"`       // 手动获取
-	Creator      *TaskMember   `json:"creator,omitempty" gorm:"-"`      // 手动获取
-	Collaborator []*TaskMember `json:"collaborator,omitempty" gorm:"-"` // 手动获取
-	Group        *TaskGroup    `json:"group"`                           // 一对一
+	ProjectId    uint             `json:"project_id" gorm:"index:project_id"`
+	GroupId      uint             `json:"group_id" gorm:"index:project_id"`
+	ParentId     uint             `json:"parent_id" gorm:"index:parent_id"` // 父任务ID，0表示顶级任务
+	Title        string           `json:"title" gorm:"size:256"`
+	Describe     string           `json:"describe,omitempty" gorm:""`
+	Status       uint8            `json:"status" gorm:"index:project_id"` // 状态分类
+	StatusId     uint             `json:"status_id" gorm:"default:0"`     // 项目自定义的状态，0表示项目没有自定义状态
+	Level        uint             `json:"level" gorm:"index:project_id"`
+	CompleteDate int64            `json:"complete_date" gorm:"default:null"`
+	ArchivedDate int64            `json:"archived_date" gorm:"default:null"`
+	StartDate    int64            `json:"start_date" gorm:"default:null"`
+	EndDate      int64            `json:"end_date" gorm:"default:null"`
+	EnclosureNum uint             `json:"enclosure_num"`
+	DialogId     uint             `json:"dialog_id" gorm:"default:0"`
+	RecurrenceId uint             `json:"recurrence_id" gorm:"default:0"` // 由该重复规则自动生成
+	PlanTime     []int64          `json:"plan_time" gorm:"-"`
+	Project      *Project         `json:"project,omitempty"` // 一对多（反向）
+	Member       []*TaskMember    `json:"member,omitempty" gorm:"foreignKey:TaskId"`
+	Leader       *TaskMember      `json:"leader,omitempty" gorm:"-"`       // 手动获取
+	Creator      *TaskMember      `json:"creator,omitempty" gorm:"-"`      // 手动获取
+	Collaborator []*TaskMember    `json:"collaborator,omitempty" gorm:"-"` // 手动获取
+	Group        *TaskGroup       `json:"group"`                           // 一对一
+	StatusInfo   *TaskStatus      `json:"status_info,omitempty" gorm:"-:migration;foreignKey:StatusId"`
+	Workflows    []Workflow       `json:"workflows,omitempty" gorm:"-"`  // 关联的审批，手动获取
+	Blockers     []TaskDependency `json:"blockers,omitempty" gorm:"-"`   // 前置任务，手动获取
+	Dependents   []TaskDependency `json:"dependents,omitempty" gorm:"-"` // 后续任务，手动获取
+	Children     []*Task          `json:"children,omitempty" gorm:"-"`   // 子任务，手动获取
+	Recurrence   *TaskRecurrence  `json:"recurrence,omitempty" gorm:"-"` // 重复规则，手动获取
+	Fields       []TaskFieldValue `json:"fields,omitempty" gorm:"-"`     // 自定义字段值，手动获取
+	Comments     []*TaskComment   `json:"comments,omitempty" gorm:"-"`   // 评论，按讨论串组织，手动获取
+	// 子任务进度，包含所有层级的子任务
+	SubtaskTotal     int `json:"subtask_total" gorm:"-"`
+	SubtaskCompleted int `json:"subtask_completed" gorm:"-"`
+	Progress         int `json:"progress" gorm:"-"` // 已完成子任务的百分比
 }
 
 func (receiver Task) TableName() string {
@@ -45,4 +60,9 @@ type TaskRepo interface {
 	GetTasksByProject(projectId uint, status []int) ([]Task, error)
 	CompletedQuantity(projectId uint, completeTime []int64) (int64, error)
 	CreatedQuantity(projectId uint, createTime []int64) (int64, error)
+	// GetChildren 获取这些任务的直接子任务
+	GetChildren(parentIds []uint) ([]Task, error)
+	UpdateFieldsByIds(ids []uint, values interface{}) error
+	// ExistStatus 是否有任务使用该自定义状态
+	ExistStatus(statusId uint) bool
 }
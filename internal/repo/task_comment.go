@@ -0,0 +1,55 @@
+package repo
+
+// TaskComment 任务评论
+// 删除时只记录删除时间并清空内容，保留记录以维持讨论串，原内容保存在历史中
+type TaskComment struct {
+	BaseModel
+	TaskId     uint   `json:"task_id" gorm:"index:task_id"`
+	ParentId   uint   `json:"parent_id" gorm:"index:parent_id"` // 回复的评论，0表示顶级评论
+	Content    string `json:"content" gorm:"type:text"`         // Markdown 格式
+	Mentions   string `json:"-" gorm:"size:1024"`               // 提及的用户ID，json数组字符串
+	Creator    uint64 `json:"creator"`
+	EditTime   int64  `json:"edit_time"`   // 最后编辑时间，毫秒，0表示未编辑
+	DeleteTime int64  `json:"delete_time"` // 删除时间，毫秒，0表示未删除
+	// -:migration 在迁移时忽略该字段
+	CreatorInfo  *User          `json:"creator_info,omitempty" gorm:"-:migration;foreignKey:Creator"`
+	MentionUsers []User         `json:"mentions" gorm:"-"`          // 提及的用户，手动获取
+	Replies      []*TaskComment `json:"replies,omitempty" gorm:"-"` // 回复，手动获取
+}
+
+func (receiver TaskComment) TableName() string {
+	return GetTablePrefix() + "task_comment"
+}
+
+// TaskCommentHistory 任务评论的编辑与删除历史，保存操作前的内容
+type TaskCommentHistory struct {
+	BaseModel
+	CommentId    uint   `json:"comment_id" gorm:"index:comment_id"`
+	Action       string `json:"action" gorm:"size:16"` // 编辑或删除
+	Content      string `json:"content" gorm:"type:text"`
+	Mentions     string `json:"-" gorm:"size:1024"`
+	Operator     uint64 `json:"operator"`
+	OperatorInfo *User  `json:"operator_info,omitempty" gorm:"-:migration;foreignKey:Operator"`
+}
+
+func (receiver TaskCommentHistory) TableName() string {
+	return GetTablePrefix() + "task_comment_history"
+}
+
+type TaskCommentRepo interface {
+	Create(data *TaskComment) error
+	Get(id uint) (*TaskComment, error)
+	UpdateFields(id uint, values interface{}) error
+	// GetTaskComments 获取任务的所有评论，包括已删除的，预加载评论人
+	GetTaskComments(taskId uint) ([]TaskComment, error)
+	// DeleteByTask 删除任务的所有评论
+	DeleteByTask(taskId uint) error
+}
+
+type TaskCommentHistoryRepo interface {
+	Create(data *TaskCommentHistory) error
+	// GetCommentHistories 获取评论的历史，最新的在前，预加载操作人
+	GetCommentHistories(commentId uint) ([]TaskCommentHistory, error)
+	// DeleteByTask 删除任务所有评论的历史，需要在删除评论前执行
+	DeleteByTask(taskId uint) error
+}
@@ -0,0 +1,37 @@
+package repo
+
+// TaskDependency 任务依赖
+// TaskId 依赖 DependId，DependId 完成前 TaskId 不能完成
+type TaskDependency struct {
+	BaseModel
+	TaskId   uint   `json:"task_id" gorm:"uniqueIndex:task_depend"`
+	DependId uint   `json:"depend_id" gorm:"uniqueIndex:task_depend;index:depend_id"`
+	Creator  uint64 `json:"creator"`
+	Task     *Task  `json:"task,omitempty" gorm:"-:migration;foreignKey:TaskId"`     // 依赖方
+	Depend   *Task  `json:"depend,omitempty" gorm:"-:migration;foreignKey:DependId"` // 被依赖方
+}
+
+func (receiver TaskDependency) TableName() string {
+	return GetTablePrefix() + "task_dependency"
+}
+
+// TaskDependencyList 任务的前置任务与后续任务
+type TaskDependencyList struct {
+	Blockers   []TaskDependency `json:"blockers"`   // 当前任务依赖的任务
+	Dependents []TaskDependency `json:"dependents"` // 依赖当前任务的任务
+}
+
+type TaskDependencyRepo interface {
+	Create(data *TaskDependency) error
+	Delete(id uint) error
+	Get(id uint) (*TaskDependency, error)
+	Exist(taskId, dependId uint) bool
+	// GetBlockers 获取任务依赖的任务，预加载被依赖的任务
+	GetBlockers(taskId uint) ([]TaskDependency, error)
+	// GetDependents 获取依赖该任务的任务，预加载依赖方任务
+	GetDependents(taskId uint) ([]TaskDependency, error)
+	// GetDependIds 获取这些任务直接依赖的任务ID
+	GetDependIds(taskIds []uint) ([]uint, error)
+	// DeleteByTask 删除任务作为任意一方的依赖
+	DeleteByTask(taskId uint) error
+}
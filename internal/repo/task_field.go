@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"VitaTaskGo/internal/api/model/dto"
+)
+
+// TaskField 项目的任务自定义字段
+type TaskField struct {
+	BaseModel
+	DeletedAt
+	ProjectId uint   `json:"project_id" gorm:"index:project_id"`
+	Name      string `json:"name" gorm:"size:64"`
+	Type      string `json:"type" gorm:"size:16"`      // 字段类型 text number date select multi_select user
+	Options   string `json:"options" gorm:"type:text"` // 单选、多选的选项，json数组字符串
+	Required  int8   `json:"required"`                 // 是否必填 1-是 0-否
+	Sort      int    `json:"sort"`
+}
+
+func (receiver TaskField) TableName() string {
+	return GetTablePrefix() + "task_field"
+}
+
+type TaskFieldRepo interface {
+	Create(data *TaskField) error
+	Save(data *TaskField) error
+	Delete(id uint) error
+	Get(id uint) (*TaskField, error)
+	// GetProjectFields 获取项目的所有自定义字段，按排序升序
+	GetProjectFields(projectId uint) ([]TaskField, error)
+	// ExistName 项目中是否已存在同名字段，excludeId 为排除的字段
+	ExistName(projectId uint, name string, excludeId uint) bool
+}
+
+// TaskFieldValue 任务的自定义字段值
+type TaskFieldValue struct {
+	BaseModel
+	TaskId  uint   `json:"task_id" gorm:"uniqueIndex:task_field"`
+	FieldId uint   `json:"field_id" gorm:"uniqueIndex:task_field;index:field_id"`
+	Value   string `json:"value" gorm:"type:text"` // 统一保存为字符串，多选为json数组字符串
+	// 用于展示的值，成员类型为昵称，手动获取
+	Display string     `json:"display" gorm:"-"`
+	Field   *TaskField `json:"field,omitempty" gorm:"-:migration;foreignKey:FieldId"`
+}
+
+func (receiver TaskFieldValue) TableName() string {
+	return GetTablePrefix() + "task_field_value"
+}
+
+type TaskFieldValueRepo interface {
+	// Save 保存字段值，任务的同一字段已存在时更新
+	Save(data *TaskFieldValue) error
+	// GetTaskValues 获取任务的所有字段值，预加载字段定义
+	GetTaskValues(taskId uint) ([]TaskFieldValue, error)
+	// GetTaskIds 获取字段值符合筛选条件的任务ID
+	GetTaskIds(field *TaskField, filter dto.TaskFieldFilter) ([]uint, error)
+	// DeleteByFields 删除任务这些字段的值
+	DeleteByFields(taskId uint, fieldIds []uint) error
+	DeleteByTask(taskId uint) error
+	DeleteByField(fieldId uint) error
+}
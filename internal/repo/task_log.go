@@ -6,14 +6,16 @@ import (
 
 type TaskLog struct {
 	BaseModel
-	ID           uint64 `gorm:"primaryKey" json:"id"`
-	TaskId       uint   `json:"task_id" gorm:"index:task_id" on:"task_id"`
-	OperateType  string `json:"operate_type"`
-	Operator     uint64 `json:"operator"`
-	OperateTime  int64  `json:"operate_time"`
-	Message      string `json:"message"`
-	Task         *Task  `json:"task"`
-	OperatorInfo *User  `json:"operator_info" gorm:"foreignKey:ID;references:operator"`
+	ID           uint64              `gorm:"primaryKey" json:"id"`
+	TaskId       uint                `json:"task_id" gorm:"index:task_id" on:"task_id"`
+	OperateType  string              `json:"operate_type"`
+	Operator     uint64              `json:"operator"`
+	OperateTime  int64               `json:"operate_time"`
+	Message      string              `json:"message"`
+	Changes      string              `json:"-" gorm:"type:text"`         // 字段级的变更明细，json数组字符串
+	ChangeList   []dto.TaskLogChange `json:"changes,omitempty" gorm:"-"` // 查询时解析并渲染
+	Task         *Task               `json:"task"`
+	OperatorInfo *User               `json:"operator_info" gorm:"foreignKey:ID;references:operator"`
 }
 
 func (receiver TaskLog) TableName() string {
@@ -24,4 +24,5 @@ type TaskMemberRepo interface {
 	InTask(taskId uint, userId uint64, roles []int) bool
 	GetMembersByRole(taskId uint, roles []int) ([]TaskMember, error)
 	GetTaskIdsByUsers(userIds []uint64, role []int) ([]uint, error)
+	DeleteTaskAllMember(taskId uint) error
 }
@@ -0,0 +1,40 @@
+package repo
+
+// TaskRecurrence 任务重复规则
+// 以 TaskId 对应的任务为模板，按规则定期生成新任务
+type TaskRecurrence struct {
+	BaseModel
+	DeletedAt
+	TaskId    uint   `json:"task_id" gorm:"uniqueIndex:task_id"` // 模板任务
+	ProjectId uint   `json:"project_id" gorm:"index:project_id"`
+	Rule      string `json:"rule" gorm:"size:128"` // RRULE 格式，例如 FREQ=WEEKLY;BYDAY=MO,FR
+	StartDate int64  `json:"start_date"`           // 开始日期，毫秒
+	EndDate   int64  `json:"end_date"`             // 结束日期，毫秒，0表示不结束
+	// 下一次生成任务的时间，毫秒，0表示已结束
+	NextTime int64 `json:"next_time" gorm:"index:next_time"`
+	// 上一次生成任务的时间，毫秒
+	LastTime int64  `json:"last_time"`
+	Count    int    `json:"count"`   // 已生成的任务数量
+	Creator  uint64 `json:"creator"` // 以该用户的身份生成任务
+	Task     *Task  `json:"task,omitempty" gorm:"-:migration;foreignKey:TaskId"`
+	// 接下来几次生成任务的时间，手动获取
+	NextTimes []int64 `json:"next_times,omitempty" gorm:"-"`
+}
+
+func (receiver TaskRecurrence) TableName() string {
+	return GetTablePrefix() + "task_recurrence"
+}
+
+type TaskRecurrenceRepo interface {
+	Save(data *TaskRecurrence) error
+	Delete(id uint) error
+	GetByTask(taskId uint) (*TaskRecurrence, error)
+	// GetDue 获取到期需要生成任务的规则
+	GetDue(now int64) ([]TaskRecurrence, error)
+	UpdateFields(id uint, values interface{}) error
+	// Claim 下一次生成时间仍为 nextTime 时更新规则，返回是否更新成功
+	// 多个实例同时扫描时，同一次生成只有一个实例能认领
+	Claim(id uint, nextTime int64, values interface{}) (bool, error)
+	// DeleteByTask 删除模板任务的重复规则
+	DeleteByTask(taskId uint) error
+}
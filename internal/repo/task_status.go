@@ -0,0 +1,35 @@
+package repo
+
+// TaskStatus 项目自定义的任务状态
+type TaskStatus struct {
+	BaseModel
+	DeletedAt
+	ProjectId uint   `json:"project_id" gorm:"index:project_id"`
+	Name      string `json:"name" gorm:"size:64"`
+	Category  int    `json:"category"` // 状态分类 0-未完成 1-已完成 2-已归档
+	Color     string `json:"color" gorm:"size:16"`
+	Sort      int    `json:"sort"`
+	IsDefault int8   `json:"is_default"` // 是否为新任务的初始状态 1-是 0-否
+	// 允许流转到的状态ID，json数组字符串，为空时不限制
+	Transitions string `json:"transitions" gorm:"type:text"`
+}
+
+func (receiver TaskStatus) TableName() string {
+	return GetTablePrefix() + "task_status"
+}
+
+type TaskStatusRepo interface {
+	Create(data *TaskStatus) error
+	Save(data *TaskStatus) error
+	Delete(id uint) error
+	Get(id uint) (*TaskStatus, error)
+	// GetProjectStatuses 获取项目的所有状态，按排序升序
+	GetProjectStatuses(projectId uint) ([]TaskStatus, error)
+	// GetFirstByCategory 获取项目中该分类排序最靠前的状态
+	GetFirstByCategory(projectId uint, category int) (*TaskStatus, error)
+	// GetDefault 获取项目的初始状态，没有设置时使用排序最靠前的未完成状态
+	GetDefault(projectId uint) (*TaskStatus, error)
+	ExistName(projectId uint, name string, excludeId uint) bool
+	// ClearDefault 取消项目中其它状态的初始状态标记
+	ClearDefault(projectId uint, excludeId uint) error
+}
@@ -60,4 +60,6 @@ type UserRepo interface {
 	UpdateUserPass(uint64, string) error
 	UpdateUserSuper(uint64, int8) error
 	GetAdministrators() ([]User, error)
+	// GetUsers 批量获取已启用的用户
+	GetUsers([]uint64) ([]User, error)
 }
@@ -8,11 +8,15 @@ import (
 type Workflow struct {
 	BaseModel
 	DeletedAt
-	TypeId   uint   `json:"type_id,omitempty"`
+	TypeId   uint   `json:"type_id,omitempty" gorm:"uniqueIndex:serials,priority:1"`
 	TypeName string `json:"type_name,omitempty"`
 	OrgId    uint   `json:"org_id,omitempty"`
-	// 工作流编号
-	Serials string `json:"serials,omitempty"`
+	// 关联的项目，0表示不关联
+	ProjectId uint `json:"project_id,omitempty" gorm:"index:project_id"`
+	// 关联的任务，0表示不关联
+	TaskId uint `json:"task_id,omitempty" gorm:"index:task_id"`
+	// 工作流编号，同一类型内唯一
+	Serials string `json:"serials,omitempty" gorm:"size:64;uniqueIndex:serials,priority:2"`
 	Title   string `json:"title,omitempty"`
 	// 发起人ID
 	Promoter uint64 `json:"promoter,omitempty"`
@@ -24,11 +28,19 @@ type Workflow struct {
 	Node int `json:"node,omitempty"`
 	// 提交次数
 	SubmitNum int `json:"submit_num,omitempty"`
+	// 发起时的定义版本，0表示未使用版本
+	Version int `json:"version"`
 	// 关联工作流节点表，指定用本表的Node字段关联WorkflowNode表的Node字段
 	NodeInfo *WorkflowNode      `json:"node_info" gorm:"-:migration;foreignKey:Node;references:Node"`
 	Operator []WorkflowOperator `json:"operator" gorm:"-:migration;WorkflowId:Node;references:ID"`
+	// 并行分支
+	Tokens []WorkflowToken `json:"tokens,omitempty" gorm:"-:migration;foreignKey:WorkflowId;references:ID"`
+	// 并行分支当前所在的节点
+	BranchNodes []*WorkflowNode `json:"branch_nodes,omitempty" gorm:"-"`
 	// 状态名 英文
 	StatusText string `json:"status_text,omitempty" gorm:"-"`
+	// 当前节点的会签进度
+	VoteProgress *WorkflowVoteProgress `json:"vote_progress,omitempty" gorm:"-"`
 }
 
 func (receiver *Workflow) TableName() string {
@@ -44,5 +56,12 @@ type WorkflowRepo interface {
 	UpdateFields(id uint, values interface{}) error
 	PageList(query dto.WorkflowListQueryDto) ([]Workflow, int64, error)
 	SetDbInstance(tx *gorm.DB)
-	GetDayTotal(start, end int64) (int64, error)
+	// Lock 锁定工作流记录直到事务结束，必须在事务中调用
+	Lock(id uint) error
+	// CountOutdated 统计该类型中使用旧版本且处于指定状态的工作流数量
+	CountOutdated(typeId uint, version int, status []int) (int64, error)
+	// GetTaskWorkflows 获取任务关联的工作流
+	GetTaskWorkflows(taskId uint) ([]Workflow, error)
+	// ExistTaskWorkflow 任务是否有指定类型且处于指定状态的工作流
+	ExistTaskWorkflow(taskId uint, typeId uint, status int) bool
 }
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"VitaTaskGo/internal/api/model/dto"
+	"gorm.io/gorm"
+)
+
+// WorkflowCc 工作流抄送记录
+// 工作流到达配置了抄送的节点时生成，同一节点再次到达时重新标记为未读
+type WorkflowCc struct {
+	BaseModel
+	WorkflowId uint `json:"workflow_id" gorm:"uniqueIndex:workflow_node_user"`
+	// 抄送时所在节点
+	Node     int    `json:"node" gorm:"uniqueIndex:workflow_node_user"`
+	NodeName string `json:"node_name"`
+	// 抄送人ID
+	UserId   uint64 `json:"user_id" gorm:"uniqueIndex:workflow_node_user;index:user_id"`
+	Nickname string `json:"nickname"`
+	// 阅读时间，0表示未读
+	ReadTime int64 `json:"read_time"`
+	// 关联的工作流
+	Workflow *Workflow `json:"workflow,omitempty" gorm:"-:migration;foreignKey:WorkflowId"`
+}
+
+func (receiver *WorkflowCc) TableName() string {
+	return GetTablePrefix() + "workflow_cc"
+}
+
+type WorkflowCcRepo interface {
+	// Save 保存抄送记录，已存在的记录重新标记为未读
+	Save(list []WorkflowCc) error
+	PageList(query dto.WorkflowCcQueryDto) ([]WorkflowCc, int64, error)
+	// MarkRead 将用户的抄送标记为已读，ids 为空时标记全部
+	MarkRead(userId uint64, ids []uint, t int64) error
+	// UnreadCount 用户未读的抄送数量
+	UnreadCount(userId uint64) (int64, error)
+	// Exist 用户是否是该工作流的抄送人
+	Exist(workflowId uint, userId uint64) bool
+	SetDbInstance(tx *gorm.DB)
+}
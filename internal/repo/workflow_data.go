@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+)
+
+// WorkflowData 工作流表单数据
+// 每次保存都会生成一个新版本，不修改旧记录
+type WorkflowData struct {
+	BaseModel
+	WorkflowId uint `json:"workflow_id,omitempty" gorm:"uniqueIndex:workflow_version"`
+	// 数据版本，从1开始递增
+	Version int `json:"version,omitempty" gorm:"uniqueIndex:workflow_version"`
+	// 保存时所在的节点
+	Node int `json:"node"`
+	// 保存人ID
+	Operator uint64 `json:"operator,omitempty"`
+	// 保存人昵称
+	Nickname string `json:"nickname,omitempty"`
+	// 表单数据，json字符串
+	Data string `json:"-" gorm:"type:text"`
+	// 解析后的表单数据
+	Content map[string]interface{} `json:"content" gorm:"-"`
+}
+
+func (receiver *WorkflowData) TableName() string {
+	return GetTablePrefix() + "workflow_data"
+}
+
+func (receiver *WorkflowData) AfterFind(*gorm.DB) (err error) {
+	// 解析表单数据
+	if receiver.Data != "" {
+		err = json.Unmarshal([]byte(receiver.Data), &receiver.Content)
+	}
+	return
+}
+
+type WorkflowDataRepo interface {
+	Create(data *WorkflowData) error
+	Get(id uint) (*WorkflowData, error)
+	// GetLatest 获取该工作流最新版本的数据
+	GetLatest(workflowId uint) (*WorkflowData, error)
+	// GetVersions 获取该工作流所有版本的数据，新版本在前
+	GetVersions(workflowId uint) ([]WorkflowData, error)
+	SetDbInstance(tx *gorm.DB)
+}
@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+)
+
+// WorkflowDelegation 工作流委托规则
+// 在有效期内，委托人需要审批的节点由代理人处理
+type WorkflowDelegation struct {
+	BaseModel
+	DeletedAt
+	// 委托人ID
+	UserId uint64 `json:"user_id" gorm:"index:user_id"`
+	// 委托人昵称
+	Nickname string `json:"nickname"`
+	// 代理人ID
+	DelegateId uint64 `json:"delegate_id"`
+	// 代理人昵称
+	DelegateNickname string `json:"delegate_nickname"`
+	// 有效期开始时间
+	StartTime int64 `json:"start_time"`
+	// 有效期结束时间
+	EndTime int64 `json:"end_time"`
+	// 适用的工作流类型ID，json数组字符串，为空表示全部类型
+	TypeIds string `json:"-" gorm:"size:500"`
+	// 解析后的工作流类型ID
+	TypeIdList []uint `json:"type_ids" gorm:"-"`
+	Remark     string `json:"remark"`
+}
+
+func (receiver *WorkflowDelegation) TableName() string {
+	return GetTablePrefix() + "workflow_delegation"
+}
+
+func (receiver *WorkflowDelegation) AfterFind(*gorm.DB) (err error) {
+	// 解析工作流类型ID
+	if receiver.TypeIds != "" {
+		err = json.Unmarshal([]byte(receiver.TypeIds), &receiver.TypeIdList)
+	}
+	return
+}
+
+// Match 规则是否适用于该工作流类型
+func (receiver *WorkflowDelegation) Match(typeId uint) bool {
+	if len(receiver.TypeIdList) <= 0 {
+		return true
+	}
+	for _, id := range receiver.TypeIdList {
+		if id == typeId {
+			return true
+		}
+	}
+	return false
+}
+
+type WorkflowDelegationRepo interface {
+	Create(data *WorkflowDelegation) error
+	Save(data *WorkflowDelegation) error
+	Delete(id uint) error
+	Get(id uint) (*WorkflowDelegation, error)
+	// GetUserDelegations 获取用户创建的所有委托规则
+	GetUserDelegations(userId uint64) ([]WorkflowDelegation, error)
+	// GetActive 获取用户在指定时间生效的委托规则，最新创建的在前
+	GetActive(userId uint64, at int64) ([]WorkflowDelegation, error)
+	SetDbInstance(tx *gorm.DB)
+}
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"gorm.io/gorm"
+)
+
+type WorkflowLog struct {
+	BaseModel
+	WorkflowId uint `json:"workflow_id" gorm:"index:workflow_id"`
+	// 操作类型
+	Action string `json:"action" gorm:"size:30"`
+	// 操作时所在节点
+	Node     int    `json:"node"`
+	NodeName string `json:"node_name"`
+	// 流转到的节点，0表示工作流已结束
+	TargetNode     int    `json:"target_node"`
+	TargetNodeName string `json:"target_node_name"`
+	// 操作人ID
+	Operator uint64 `json:"operator"`
+	// 操作人昵称
+	Nickname    string `json:"nickname"`
+	OperateTime int64  `json:"operate_time"`
+	// 代理审批时，被代理人ID
+	OnBehalfOf uint64 `json:"on_behalf_of,omitempty"`
+	// 代理审批时，被代理人昵称
+	OnBehalfNickname string `json:"on_behalf_nickname,omitempty"`
+	// 审批意见
+	Comment      string `json:"comment" gorm:"type:text"`
+	Message      string `json:"message"`
+	OperatorInfo *User  `json:"operator_info" gorm:"-:migration;foreignKey:ID;references:Operator"`
+	// 操作类型名称
+	ActionText string `json:"action_text" gorm:"-"`
+}
+
+func (receiver *WorkflowLog) TableName() string {
+	return GetTablePrefix() + "workflow_log"
+}
+
+type WorkflowLogRepo interface {
+	Create(data *WorkflowLog) error
+	Get(id uint) (*WorkflowLog, error)
+	// GetTimeline 按时间顺序获取该工作流的所有日志
+	GetTimeline(workflowId uint) ([]WorkflowLog, error)
+	// ExistOperator 用户是否操作过该工作流，包括被代理审批
+	ExistOperator(workflowId uint, userId uint64) bool
+	SetDbInstance(tx *gorm.DB)
+}
@@ -14,6 +14,36 @@ type WorkflowNode struct {
 	Action      string `json:"action"`
 	ActionValue string `json:"action_value"`
 	Everyone    int    `json:"everyone"`
+	// 会签模式 any-任意一人同意 all-所有人同意 vote-按人数或比例同意，为空时根据 Everyone 判断
+	ApproveMode string `json:"approve_mode" gorm:"size:20"`
+	// 按人数同意时需要的同意人数
+	ApproveCount int `json:"approve_count"`
+	// 按比例同意时需要的同意比例(1-100)
+	ApprovePercent int `json:"approve_percent"`
+	// 驳回计票 1-驳回只作为反对票，无法达到通过条件时才驳回 0-任意一人驳回即驳回
+	CountReject int8 `json:"count_reject"`
+	// 条件分支，json数组字符串，按顺序匹配第一个满足条件的分支
+	Branches string `json:"branches" gorm:"type:text"`
+	// 审批超时时间(分钟)，0表示不限制
+	Timeout int `json:"timeout"`
+	// 超时动作 remind-提醒 escalate-转交给备用审批人 approve-自动同意
+	TimeoutAction string `json:"timeout_action" gorm:"size:30"`
+	// 备用审批人，json数组字符串
+	FallbackUsers string `json:"fallback_users"`
+	// 并行分支，json数组字符串，值为各分支的第一个节点序号。节点通过后同时进入所有分支
+	Fork string `json:"fork"`
+	// 汇聚方式 all-等待所有分支 any-任意一个分支到达，为空表示不是汇聚节点
+	JoinMode string `json:"join_mode" gorm:"size:20"`
+	// 是否允许审批人加签 1-是 0-否
+	AllowAddSigner int8 `json:"allow_add_signer"`
+	// 是否允许审批人转交 1-是 0-否
+	AllowTransfer int8 `json:"allow_transfer"`
+	// 抄送人，json数组字符串
+	CcUsers string `json:"cc_users"`
+	// 按节点动作获取抄送人，例如项目负责人
+	CcAction string `json:"cc_action" gorm:"size:50"`
+	// 抄送动作的配置，与 ActionValue 格式相同
+	CcActionValue string `json:"cc_action_value"`
 }
 
 func (receiver *WorkflowNode) TableName() string {
@@ -33,4 +63,6 @@ type WorkflowNodeRepo interface {
 	GetNextNode(typeId uint, currNode int) (*WorkflowNode, error)
 	SetDbInstance(tx *gorm.DB)
 	FirstNode(typeId uint) (*WorkflowNode, error)
+	// GetTypeNodes 按节点序号获取该类型的所有节点
+	GetTypeNodes(typeId uint) ([]WorkflowNode, error)
 }
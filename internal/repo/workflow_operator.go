@@ -16,6 +16,33 @@ type WorkflowOperator struct {
 	WorkflowId uint `json:"workflow_id,omitempty"`
 	// 是否已处理
 	Handled int `json:"handled,omitempty"`
+	// 投票 approve-同意 reject-反对
+	Vote string `json:"vote,omitempty" gorm:"size:20"`
+	// 投票时间
+	VoteTime int64 `json:"vote_time,omitempty"`
+	// 被代理的原操作人ID，为0表示没有代理
+	OriginalUserId uint64 `json:"original_user_id,omitempty"`
+	// 被代理的原操作人昵称
+	OriginalNickname string `json:"original_nickname,omitempty"`
+	// 成为操作人的时间，用于计算审批超时
+	CreateTime int64 `json:"create_time" gorm:"autoCreateTime:milli"`
+	// 最近一次执行超时动作的时间
+	TimeoutTime int64 `json:"timeout_time,omitempty"`
+	// 审批截止时间，0表示不限制
+	Deadline int64 `json:"deadline,omitempty" gorm:"index:deadline"`
+	// 加签方式 before-前加签 after-后加签，为空表示节点配置的操作人
+	SignType string `json:"sign_type,omitempty" gorm:"size:20"`
+	// 加签发起人ID
+	SignBy uint64 `json:"sign_by,omitempty"`
+}
+
+// WorkflowVoteProgress 当前节点的会签进度
+type WorkflowVoteProgress struct {
+	Mode     string `json:"mode"`
+	Total    int    `json:"total"`
+	Approved int    `json:"approved"`
+	Rejected int    `json:"rejected"`
+	Required int    `json:"required"`
 }
 
 func (receiver *WorkflowOperator) TableName() string {
@@ -38,4 +65,12 @@ type WorkflowOperatorRepo interface {
 	RemoveWorkflowAllOperator(workflowId uint) error
 	// SetHandled 将当前步骤的指定操作人改为已操作的状态
 	SetHandled(workflowId uint, node int, userId uint64) error
+	// SetVote 记录当前步骤指定操作人的投票，并改为已操作的状态
+	SetVote(workflowId uint, node int, userId uint64, vote string, t int64) error
+	// SetTimeout 记录操作人执行超时动作的时间，并设置新的截止时间
+	SetTimeout(ids []uint, t int64, deadline int64) error
+	// GetTimeoutWorkflowIds 获取当前节点有操作人审批超时的工作流ID
+	GetTimeoutWorkflowIds(status int, now int64) ([]uint, error)
+	// ExistOperator 用户是否是该工作流任意节点的操作人
+	ExistOperator(workflowId uint, userId uint64) bool
 }
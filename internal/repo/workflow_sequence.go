@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"gorm.io/gorm"
+)
+
+// WorkflowSequence 工作流编号序列
+// 每个计数范围在每个日期下只有一条记录
+type WorkflowSequence struct {
+	ID uint `json:"id,omitempty" gorm:"primaryKey"`
+	// 计数范围，为空表示全局计数，否则为工作流类型的 OnlyName
+	Scope string `json:"scope" gorm:"size:64;uniqueIndex:scope_date"`
+	// 按编号日期格式格式化后的日期
+	Date  string `json:"date" gorm:"size:32;uniqueIndex:scope_date"`
+	Value int64  `json:"value"`
+}
+
+func (receiver *WorkflowSequence) TableName() string {
+	return GetTablePrefix() + "workflow_sequence"
+}
+
+type WorkflowSequenceRepo interface {
+	// Next 获取下一个序号
+	// 使用行锁保证并发安全，必须在事务中调用
+	Next(scope, date string) (int64, error)
+	SetDbInstance(tx *gorm.DB)
+}
@@ -0,0 +1,26 @@
+package repo
+
+import "gorm.io/gorm"
+
+// WorkflowToken 工作流并行分支
+// 工作流在并行节点分流后，每个分支对应一条记录，分支汇聚或工作流离开并行状态时删除
+type WorkflowToken struct {
+	BaseModel
+	WorkflowId uint `json:"workflow_id" gorm:"index:workflow_id"`
+	// 分支当前所在节点
+	Node int `json:"node"`
+	// 是否已到达汇聚节点，等待其他分支 1-是 0-否
+	Waiting int8 `json:"waiting"`
+}
+
+func (receiver *WorkflowToken) TableName() string {
+	return GetTablePrefix() + "workflow_token"
+}
+
+type WorkflowTokenRepo interface {
+	// GetWorkflowTokens 获取工作流的所有分支
+	GetWorkflowTokens(workflowId uint) ([]WorkflowToken, error)
+	// Replace 使用新的分支替换工作流的所有分支
+	Replace(workflowId uint, tokens []WorkflowToken) error
+	SetDbInstance(tx *gorm.DB)
+}
@@ -16,6 +16,23 @@ type WorkflowType struct {
 	OnlyName string `json:"only_name,omitempty"`
 	// 系统级工作流类型 1-是 0-否
 	System int8 `json:"system,omitempty"`
+	// 表单结构，json数组字符串
+	FormSchema string `json:"form_schema" gorm:"type:text"`
+	// 编号前缀，为空时使用配置文件的默认值
+	SerialPrefix string `json:"serial_prefix" gorm:"size:32"`
+	// 编号日期格式(Go时间格式)，为空时使用配置文件的默认值
+	SerialDateFormat string `json:"serial_date_format" gorm:"size:32"`
+	// 编号序号长度，为0时使用配置文件的默认值
+	SerialPadding int `json:"serial_padding"`
+	// 编号是否独立计数 1-是 0-否(与其它类型共用全局计数)
+	SerialIndependent int8 `json:"serial_independent"`
+	// 关联任务变更为该状态前，需要本类型的工作流审批完成，0表示不限制
+	// 例如设置为已归档，则任务归档前必须有一个已完成的本类型工作流
+	TaskGateStatus int `json:"task_gate_status"`
+	// 当前生效的定义版本，0表示未发布，直接使用节点表
+	ActiveVersion int `json:"active_version"`
+	// 还在使用旧版本运行的工作流数量
+	OutdatedRunning int64 `json:"outdated_running" gorm:"-"`
 }
 
 func (receiver *WorkflowType) TableName() string {
@@ -34,5 +51,7 @@ type WorkflowTypeRepo interface {
 	GetOptions(keyWords string, system bool) ([]WorkflowType, error)
 	ExistByOnlyName(onlyName string) bool
 	GetNotSystemIds() ([]uint, error)
+	// GetTaskGates 获取限制任务变更为该状态的工作流类型
+	GetTaskGates(status int) ([]WorkflowType, error)
 	SetDbInstance(tx *gorm.DB)
 }
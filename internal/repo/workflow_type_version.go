@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+)
+
+// WorkflowTypeVersion 工作流定义版本
+// 发布时保存工作流类型与所有节点的快照，发布后不再修改
+type WorkflowTypeVersion struct {
+	BaseModel
+	TypeId uint `json:"type_id" gorm:"uniqueIndex:type_version"`
+	// 版本号，从1开始递增
+	Version int `json:"version" gorm:"uniqueIndex:type_version"`
+	// 快照，json字符串
+	Snapshot string `json:"-" gorm:"type:longtext"`
+	// 发布人ID
+	Publisher uint64 `json:"publisher"`
+	// 发布人昵称
+	Nickname string `json:"nickname"`
+	Remark   string `json:"remark"`
+	// 解析后的快照
+	TypeData *WorkflowType  `json:"type_data,omitempty" gorm:"-"`
+	Nodes    []WorkflowNode `json:"nodes,omitempty" gorm:"-"`
+}
+
+// workflowTypeSnapshot 快照结构
+type workflowTypeSnapshot struct {
+	Type  *WorkflowType  `json:"type"`
+	Nodes []WorkflowNode `json:"nodes"`
+}
+
+func (receiver *WorkflowTypeVersion) TableName() string {
+	return GetTablePrefix() + "workflow_type_version"
+}
+
+func (receiver *WorkflowTypeVersion) AfterFind(*gorm.DB) (err error) {
+	// 解析快照
+	if receiver.Snapshot != "" {
+		var snapshot workflowTypeSnapshot
+		err = json.Unmarshal([]byte(receiver.Snapshot), &snapshot)
+		receiver.TypeData = snapshot.Type
+		receiver.Nodes = snapshot.Nodes
+	}
+	return
+}
+
+// SetSnapshot 生成快照
+func (receiver *WorkflowTypeVersion) SetSnapshot(typeData *WorkflowType, nodes []WorkflowNode) error {
+	b, err := json.Marshal(workflowTypeSnapshot{Type: typeData, Nodes: nodes})
+	if err != nil {
+		return err
+	}
+	receiver.Snapshot = string(b)
+	receiver.TypeData = typeData
+	receiver.Nodes = nodes
+	return nil
+}
+
+type WorkflowTypeVersionRepo interface {
+	Create(data *WorkflowTypeVersion) error
+	// GetVersion 获取指定版本
+	GetVersion(typeId uint, version int) (*WorkflowTypeVersion, error)
+	// GetVersions 获取该类型的所有版本，不包含快照
+	GetVersions(typeId uint) ([]WorkflowTypeVersion, error)
+	// GetLatestVersion 获取该类型最新的版本号，没有版本时返回0
+	GetLatestVersion(typeId uint) (int, error)
+	SetDbInstance(tx *gorm.DB)
+}
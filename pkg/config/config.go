@@ -10,12 +10,14 @@ import (
 var Instances *Config
 
 type Config struct {
-	Jwt     JwtConfig     `yaml:"auth"`
-	Mysql   MySQLConfig   `yaml:"mysql"`
-	Redis   RedisConfig   `yaml:"redis"`
-	App     AppConfig     `yaml:"app"`
-	Member  MemberConfig  `yaml:"member"`
-	Gateway GatewayConfig `yaml:"gateway"`
+	Jwt      JwtConfig      `yaml:"auth"`
+	Mysql    MySQLConfig    `yaml:"mysql"`
+	Redis    RedisConfig    `yaml:"redis"`
+	App      AppConfig      `yaml:"app"`
+	Member   MemberConfig   `yaml:"member"`
+	Gateway  GatewayConfig  `yaml:"gateway"`
+	Workflow WorkflowConfig `yaml:"workflow"`
+	Task     TaskConfig     `yaml:"task"`
 }
 
 type JwtConfig struct {
@@ -54,6 +56,24 @@ type GatewayConfig struct {
 	Port int    `yaml:"port"`
 }
 
+type WorkflowConfig struct {
+	Serial WorkflowSerialConfig `yaml:"serial"`
+	// 审批超时扫描间隔(秒)，小于等于0时不扫描
+	TimeoutScanInterval int `yaml:"timeoutScanInterval"`
+}
+
+type TaskConfig struct {
+	// 重复任务扫描间隔(秒)，小于等于0时不生成重复任务
+	RecurrenceScanInterval int `yaml:"recurrenceScanInterval"`
+}
+
+// WorkflowSerialConfig 工作流编号默认模板，工作流类型可以单独覆盖
+type WorkflowSerialConfig struct {
+	Prefix     string `yaml:"prefix"`
+	DateFormat string `yaml:"dateFormat"` // Go时间格式，序号在格式化后的日期变化时重置
+	Padding    int    `yaml:"padding"`    // 序号长度，不足时左侧补0
+}
+
 func NewConfig() *Config {
 	return &Config{
 		Jwt: JwtConfig{
@@ -85,6 +105,17 @@ func NewConfig() *Config {
 		Member: MemberConfig{
 			DefaultPass: "123456",
 		},
+		Workflow: WorkflowConfig{
+			Serial: WorkflowSerialConfig{
+				Prefix:     "",
+				DateFormat: "20060102",
+				Padding:    4,
+			},
+			TimeoutScanInterval: 60,
+		},
+		Task: TaskConfig{
+			RecurrenceScanInterval: 300,
+		},
 	}
 }
 
@@ -1,6 +1,7 @@
 package exception
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -33,3 +34,9 @@ func NewException(code int, args ...interface{}) error {
 		Message: message,
 	}
 }
+
+// IsCode err 是否为指定错误码的异常
+func IsCode(err error, code int) bool {
+	var e *Exception
+	return errors.As(err, &e) && e.Code == code
+}
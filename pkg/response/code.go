@@ -48,6 +48,25 @@ const (
 	TaskCreatorRemove         = 2105 // 移除创建人
 	TaskDeleteFail            = 2106 // 任务删除失败
 	TaskStatusProcessing      = 2107 // 任务仍在进行中
+	TaskStatusNotProcessing   = 2108 // 任务不是进行中状态
+	TaskApprovalRequired      = 2109 // 任务需要审批通过
+	TaskDependencyCycle       = 2110 // 任务依赖形成环
+	TaskDependencyExist       = 2111 // 任务依赖已存在
+	TaskDependencyNotExist    = 2112 // 任务依赖不存在
+	TaskDependencyInvalid     = 2113 // 任务依赖不合法
+	TaskBlocked               = 2114 // 前置任务未完成
+	TaskParentInvalid         = 2115 // 父任务不合法
+	TaskRecurrenceRuleInvalid = 2116 // 重复规则不合法
+	TaskRecurrenceNotExist    = 2117 // 重复规则不存在
+	TaskFieldNotExist         = 2118 // 自定义字段不存在
+	TaskFieldInvalid          = 2119 // 自定义字段不合法
+	TaskFieldValueInvalid     = 2120 // 自定义字段值不合法
+	TaskStatusInvalid         = 2121 // 任务状态不合法
+	TaskStatusTransition      = 2122 // 任务状态不允许流转
+	TaskStatusInUse           = 2123 // 任务状态正在使用
+	TaskCommentNotExist       = 2124 // 任务评论不存在
+	TaskCommentInvalid        = 2125 // 任务评论不合法
+	TaskCommentNoPermission   = 2126 // 无权操作该评论
 
 	TaskGroupNotExist = 2200 // 任务组不存在
 
@@ -81,6 +100,26 @@ const (
 	WorkflowNodeUpdateFail               = 6007 // 工作流节点更新失败
 	WorkflowNodeDeleteFail               = 6008 // 工作流节点删除失败
 	WorkflowNodeNotExist                 = 6009 // 工作流节点不存在
+	WorkflowNodeBranchInvalid            = 6010 // 工作流节点分支配置错误
+	WorkflowNodeConditionInvalid         = 6011 // 工作流节点条件表达式错误
+	WorkflowFormSchemaInvalid            = 6012 // 工作流表单结构错误
+	WorkflowDataNotExist                 = 6013 // 工作流数据不存在
+	WorkflowTypeSerialInvalid            = 6014 // 工作流编号模板不合法
+	WorkflowDelegationNotExist           = 6015 // 工作流委托规则不存在
+	WorkflowDelegationInvalid            = 6016 // 工作流委托规则不合法
+	WorkflowDelegationSaveFail           = 6017 // 工作流委托规则保存失败
+	WorkflowNodeTimeoutInvalid           = 6018 // 工作流节点超时设置错误
+	WorkflowNodeApproveModeInvalid       = 6019 // 工作流节点会签设置错误
+	WorkflowNodeActionValueInvalid       = 6020 // 工作流节点动作配置错误
+	WorkflowDefinitionInvalid            = 6021 // 工作流定义文档错误
+	WorkflowDefinitionImportFail         = 6022 // 工作流定义导入失败
+	WorkflowTypeVersionNotExist          = 6023 // 工作流定义版本不存在
+	WorkflowTypePublishFail              = 6024 // 工作流定义发布失败
+	WorkflowNodeParallelInvalid          = 6025 // 工作流节点并行设置错误
+	WorkflowNodeCcInvalid                = 6026 // 工作流节点抄送设置错误
+	WorkflowTaskGateInvalid              = 6027 // 工作流类型的任务审批状态不合法
+	WorkflowRelationInvalid              = 6028 // 工作流关联的项目或任务不匹配
+	WorkflowViewDenied                   = 6029 // 无权查看工作流
 	WorkflowEngineNotInitialized         = 6100 // 工作流未初始化
 	WorkflowEngineNoFirstNodeSet         = 6101 // 工作流未设置起始节点
 	WorkflowEngineSerialGenerationFailed = 6102 // 工作流编号生成失败
@@ -91,6 +130,19 @@ const (
 	WorkflowEngineOperatorHandleFail     = 6107 // 操作人修改为已操作状态失败
 	WorkflowEngineSaveMainDataFail       = 6108 // 工作流更新主数据失败
 	WorkflowEngineSaveOperatorFail       = 6109 // 工作流保存操作人失败
+	WorkflowFormDataInvalid              = 6110 // 工作流表单数据校验失败
+	WorkflowEngineSaveDataFail           = 6111 // 工作流附加数据保存失败
+	WorkflowEngineNotOperator            = 6112 // 不是当前节点的操作人
+	WorkflowEngineSaveLogFail            = 6113 // 工作流日志保存失败
+	WorkflowEngineNotPromoter            = 6114 // 不是工作流的发起人
+	WorkflowEngineWithdrawDenied         = 6115 // 工作流已被处理，无法撤回
+	WorkflowEngineNoActor                = 6116 // 工作流引擎未设置操作人
+	WorkflowEngineSignerDenied           = 6117 // 当前节点不允许加签
+	WorkflowEngineTransferDenied         = 6118 // 当前节点不允许转交
+	WorkflowEngineSignerPending          = 6119 // 加签人还未处理
+	WorkflowEngineSignerInvalid          = 6120 // 加签或转交的用户不合法
+	WorkflowEngineActionInvalid          = 6121 // 审批动作不合法
+	WorkflowEngineCancelDenied           = 6122 // 无权作废工作流
 
 	TimeParseFail            = 9000 // 时间解析失败
 	ElementQuantityTooLittle = 9001 // 元素数量太少
@@ -148,6 +200,25 @@ var codeMap = map[int]string{
 	TaskCreatorRemove:         "不得移除任务创建人",
 	TaskDeleteFail:            "任务删除失败",
 	TaskStatusProcessing:      "任务仍在进行中",
+	TaskStatusNotProcessing:   "任务不是进行中状态",
+	TaskApprovalRequired:      "任务需要审批通过",
+	TaskDependencyCycle:       "任务依赖不能形成环",
+	TaskDependencyExist:       "任务依赖已存在",
+	TaskDependencyNotExist:    "任务依赖不存在",
+	TaskDependencyInvalid:     "任务依赖不合法",
+	TaskBlocked:               "前置任务未完成",
+	TaskParentInvalid:         "父任务不合法",
+	TaskRecurrenceRuleInvalid: "重复规则不合法",
+	TaskRecurrenceNotExist:    "重复规则不存在",
+	TaskFieldNotExist:         "自定义字段不存在",
+	TaskFieldInvalid:          "自定义字段不合法",
+	TaskFieldValueInvalid:     "自定义字段值不合法",
+	TaskStatusInvalid:         "任务状态不合法",
+	TaskStatusTransition:      "不允许流转到该状态",
+	TaskStatusInUse:           "任务状态正在使用",
+	TaskCommentNotExist:       "任务评论不存在",
+	TaskCommentInvalid:        "任务评论不合法",
+	TaskCommentNoPermission:   "无权操作该评论",
 
 	TaskGroupNotExist: "任务组不存在",
 
@@ -181,6 +252,26 @@ var codeMap = map[int]string{
 	WorkflowNodeUpdateFail:               "工作流节点更新失败",
 	WorkflowNodeDeleteFail:               "工作流节点删除失败",
 	WorkflowNodeNotExist:                 "工作流节点不存在",
+	WorkflowNodeBranchInvalid:            "工作流节点分支配置错误",
+	WorkflowNodeConditionInvalid:         "工作流节点条件表达式错误",
+	WorkflowFormSchemaInvalid:            "工作流表单结构错误",
+	WorkflowDataNotExist:                 "工作流数据不存在",
+	WorkflowTypeSerialInvalid:            "工作流编号模板不合法",
+	WorkflowDelegationNotExist:           "工作流委托规则不存在",
+	WorkflowDelegationInvalid:            "工作流委托规则不合法",
+	WorkflowDelegationSaveFail:           "工作流委托规则保存失败",
+	WorkflowNodeTimeoutInvalid:           "工作流节点超时设置错误",
+	WorkflowNodeApproveModeInvalid:       "工作流节点会签设置错误",
+	WorkflowNodeActionValueInvalid:       "工作流节点动作配置错误",
+	WorkflowDefinitionInvalid:            "工作流定义文档错误",
+	WorkflowDefinitionImportFail:         "工作流定义导入失败",
+	WorkflowTypeVersionNotExist:          "工作流定义版本不存在",
+	WorkflowTypePublishFail:              "工作流定义发布失败",
+	WorkflowNodeParallelInvalid:          "工作流节点并行设置错误",
+	WorkflowNodeCcInvalid:                "工作流节点抄送设置错误",
+	WorkflowTaskGateInvalid:              "工作流类型的任务审批状态不合法",
+	WorkflowRelationInvalid:              "工作流关联的项目或任务不匹配",
+	WorkflowViewDenied:                   "无权查看该工作流",
 	WorkflowEngineNotInitialized:         "工作流未初始化",
 	WorkflowEngineNoFirstNodeSet:         "工作流未设置起始节点",
 	WorkflowEngineSerialGenerationFailed: "工作流编号生成失败",
@@ -191,6 +282,19 @@ var codeMap = map[int]string{
 	WorkflowEngineOperatorHandleFail:     "操作人修改为已操作状态失败",
 	WorkflowEngineSaveMainDataFail:       "工作流主数据保存失败",
 	WorkflowEngineSaveOperatorFail:       "工作流操作人保存失败",
+	WorkflowFormDataInvalid:              "工作流表单数据校验失败",
+	WorkflowEngineSaveDataFail:           "工作流附加数据保存失败",
+	WorkflowEngineNotOperator:            "您不是当前节点的操作人",
+	WorkflowEngineSaveLogFail:            "工作流日志保存失败",
+	WorkflowEngineNotPromoter:            "不是工作流的发起人",
+	WorkflowEngineWithdrawDenied:         "工作流已被处理，无法撤回",
+	WorkflowEngineNoActor:                "工作流引擎未设置操作人",
+	WorkflowEngineSignerDenied:           "当前节点不允许加签",
+	WorkflowEngineTransferDenied:         "当前节点不允许转交",
+	WorkflowEngineSignerPending:          "请等待加签人处理后再审批",
+	WorkflowEngineSignerInvalid:          "加签或转交的用户不合法",
+	WorkflowEngineActionInvalid:          "审批动作不合法",
+	WorkflowEngineCancelDenied:           "只有发起人、管理员与当前审批人可以作废工作流",
 
 	TimeParseFail:            "时间解析失败",
 	ElementQuantityTooLittle: "元素数量太少",
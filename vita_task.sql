@@ -231,9 +231,11 @@ CREATE TABLE `vt_task` (
   `id` int(10) unsigned NOT NULL AUTO_INCREMENT,
   `project_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '项目ID',
   `group_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '项目任务组ID',
+  `parent_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '父任务ID',
   `title` varchar(255) NOT NULL DEFAULT '' COMMENT '任务标题',
   `describe` longtext COMMENT '任务描述',
   `status` tinyint(3) unsigned NOT NULL DEFAULT '0' COMMENT '任务状态',
+  `status_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '项目自定义的状态ID',
   `level` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '任务紧急度',
   `complete_date` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '完成时间',
   `archived_date` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '归档时间',
@@ -241,11 +243,13 @@ CREATE TABLE `vt_task` (
   `end_date` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '计划结束时间',
   `enclosure_num` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '附件数量',
   `dialog_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '对话ID',
+  `recurrence_id` int(10) unsigned NOT NULL DEFAULT '0' COMMENT '重复规则ID',
   `create_time` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
   `update_time` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
   `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
-  KEY `project_id` (`project_id`,`group_id`,`status`,`level`)
+  KEY `project_id` (`project_id`,`group_id`,`status`,`level`),
+  KEY `parent_id` (`parent_id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -335,6 +339,7 @@ CREATE TABLE `vt_task_log` (
   `operator` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '操作人员ID',
   `operate_time` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '操作时间',
   `message` longtext COMMENT '日志信息',
+  `changes` longtext COMMENT '变更明细',
   `create_time` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '创建时间',
   `update_time` bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '更新时间',
   PRIMARY KEY (`id`),